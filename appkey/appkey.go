@@ -0,0 +1,138 @@
+package appkey
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/errs"
+)
+
+// EncryptedFile is the name of the encrypted app key, stored in config.ProfileDir().
+const EncryptedFile = "appkey.enc"
+
+// Source loads the Farcaster app key used to sign messages.
+type Source interface {
+	Load() (ed25519.PrivateKey, error)
+}
+
+// Plain is a hex encoded (0x-prefixed) app key, as stored in farcaster.account.appkey.
+type Plain string
+
+func (p Plain) Load() (ed25519.PrivateKey, error) {
+	return Parse(string(p))
+}
+
+// Key is an already loaded app key.
+type Key ed25519.PrivateKey
+
+func (k Key) Load() (ed25519.PrivateKey, error) {
+	return ed25519.PrivateKey(k), nil
+}
+
+// Command is a shell command that prints the hex encoded app key, for example "pass show lemon3".
+type Command string
+
+func (c Command) Load() (ed25519.PrivateKey, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", string(c))
+	} else {
+		cmd = exec.Command("sh", "-c", string(c))
+	}
+	var stderr bytes.Buffer
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errs.New(errs.Config, "appkey command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	// Tools like pass print the secret on the first line.
+	line, _, _ := strings.Cut(string(out), "\n")
+	return Parse(line)
+}
+
+// File is an app key encrypted with Encrypt. Passphrase is called to get the passphrase.
+type File struct {
+	Path       string
+	Passphrase func() ([]byte, error)
+}
+
+func (f File) Load() (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, errs.Wrap(errs.Config, err)
+	}
+	passphrase, err := f.Passphrase()
+	if err != nil {
+		return nil, err
+	}
+	return Decrypt(data, passphrase)
+}
+
+/*
+Parse decodes a hex encoded ed25519 key. The key can be the 32-byte
+seed (what castkeys.xyz and most apps export) or the 64-byte private
+key, with or without the 0x prefix.
+*/
+func Parse(key string) (ed25519.PrivateKey, error) {
+	key = strings.TrimPrefix(strings.TrimSpace(key), "0x")
+	if key == "" {
+		return nil, errs.New(errs.Config, "app key is empty")
+	}
+	b, err := hex.DecodeString(key)
+	if err != nil {
+		return nil, errs.New(errs.Config, "app key is not valid hex: %w", err)
+	}
+	switch len(b) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(b), nil
+	case ed25519.PrivateKeySize:
+		pk := ed25519.NewKeyFromSeed(b[:ed25519.SeedSize])
+		if !bytes.Equal(pk, b) {
+			return nil, errs.New(errs.Config, "app key public part does not match its seed")
+		}
+		return pk, nil
+	}
+	return nil, errs.New(errs.Config, "app key must be %d bytes, got %d", ed25519.SeedSize, len(b))
+}
+
+// Hex returns the 0x-prefixed hex encoding of the key seed.
+func Hex(key ed25519.PrivateKey) string {
+	return "0x" + hex.EncodeToString(key.Seed())
+}
+
+// PublicHex returns the 0x-prefixed hex encoding of the public key.
+func PublicHex(key ed25519.PrivateKey) string {
+	return "0x" + hex.EncodeToString(key.Public().(ed25519.PublicKey))
+}
+
+// EncryptedPath returns the path of the encrypted app key file.
+func EncryptedPath() (string, error) {
+	return configPath(EncryptedFile)
+}
+
+/*
+FromConfig returns the configured app key source. In order of
+preference: farcaster.account.appkey_cmd, the encrypted key file,
+and the plaintext farcaster.account.appkey.
+*/
+func FromConfig() (Source, error) {
+	if c := config.GetString("farcaster.account.appkey_cmd"); c != "" {
+		return Command(c), nil
+	}
+	if path, err := EncryptedPath(); err == nil {
+		if _, err := os.Stat(path); err == nil {
+			return File{Path: path, Passphrase: ReadPassphrase}, nil
+		}
+	}
+	if k := config.GetString("farcaster.account.appkey"); k != "" {
+		return Plain(k), nil
+	}
+	return nil, errs.New(errs.Config, "no app key configured, run \"lemon3 setup\" or \"lemon3 key import\"")
+}
@@ -0,0 +1,82 @@
+package appkey
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/vrypan/lemon3/errs"
+)
+
+const testKey = "0x9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60"
+
+func TestParse(t *testing.T) {
+	key, err := Parse(testKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if Hex(key) != testKey {
+		t.Fatalf("expected %s, got %s", testKey, Hex(key))
+	}
+	if PublicHex(key) != "0xd75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a" {
+		t.Fatalf("unexpected public key %s", PublicHex(key))
+	}
+	if _, err := Parse("0x" + testKey[2:] + PublicHex(key)[2:]); err != nil {
+		t.Fatalf("64-byte key: unexpected error: %v", err)
+	}
+	for _, bad := range []string{"", "0x", "0x1", "0xzz", testKey[:20]} {
+		if _, err := Parse(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	key, _ := Parse(testKey)
+	data, err := Encrypt(key, []byte("secret"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bytes.Contains(data, []byte(testKey[2:])) {
+		t.Fatal("encrypted file contains the plaintext key")
+	}
+	decrypted, err := Decrypt(data, []byte("secret"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(decrypted, key) {
+		t.Fatal("decrypted key does not match")
+	}
+	if _, err := Decrypt(data, []byte("wrong")); err == nil {
+		t.Fatal("expected error for wrong passphrase")
+	}
+}
+
+func TestDecryptTampered(t *testing.T) {
+	key, _ := Parse(testKey)
+	data, err := Encrypt(key, []byte("secret"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tamper := map[string]func(e *encryptedKey){
+		"short nonce": func(e *encryptedKey) { e.Nonce = e.Nonce[:12] },
+		"no nonce":    func(e *encryptedKey) { e.Nonce = nil },
+		"short salt":  func(e *encryptedKey) { e.Salt = e.Salt[:4] },
+		"huge n":      func(e *encryptedKey) { e.N = 1 << 40 },
+		"n not power": func(e *encryptedKey) { e.N = 1000 },
+		"huge r":      func(e *encryptedKey) { e.R = 1 << 30 },
+		"zero p":      func(e *encryptedKey) { e.P = 0 },
+	}
+	for name, fn := range tamper {
+		var e encryptedKey
+		if err := json.Unmarshal(data, &e); err != nil {
+			t.Fatal(err)
+		}
+		fn(&e)
+		bad, _ := json.Marshal(e)
+		_, err := Decrypt(bad, []byte("secret"))
+		if errs.KindOf(err) != errs.Config {
+			t.Errorf("%s: expected a config error, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package appkey
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/vrypan/lemon3/errs"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+// EnvPassphrase is the environment variable used instead of prompting for the passphrase.
+const EnvPassphrase = "LEMON3_PASSPHRASE"
+
+// scrypt parameters for new files. Decrypt uses the ones stored in the file.
+const (
+	scryptN = 1 << 17
+	scryptR = 8
+	scryptP = 1
+)
+
+// Limits of the parameters read from a file, so a tampered file can not exhaust memory.
+const (
+	maxScryptMem = 1 << 30 // bytes, 128*N*R
+	maxScryptP   = 16
+	minSaltSize  = 16
+	maxSaltSize  = 64
+)
+
+type encryptedKey struct {
+	Version    int    `json:"version"`
+	Kdf        string `json:"kdf"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       []byte `json:"salt"`
+	Cipher     string `json:"cipher"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+/*
+Encrypt seals the key seed with XChaCha20-Poly1305, using a key
+derived from passphrase with scrypt. The result is a JSON document
+that can be written to EncryptedPath().
+*/
+func Encrypt(key ed25519.PrivateKey, passphrase []byte) ([]byte, error) {
+	e := encryptedKey{
+		Version: 1,
+		Kdf:     "scrypt",
+		N:       scryptN,
+		R:       scryptR,
+		P:       scryptP,
+		Salt:    make([]byte, 16),
+		Cipher:  "xchacha20-poly1305",
+		Nonce:   make([]byte, chacha20poly1305.NonceSizeX),
+	}
+	if _, err := rand.Read(e.Salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(e.Nonce); err != nil {
+		return nil, err
+	}
+	aead, err := e.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	e.Ciphertext = aead.Seal(nil, e.Nonce, key.Seed(), []byte(e.Kdf+e.Cipher))
+	return json.MarshalIndent(e, "", "  ")
+}
+
+// Decrypt opens a key sealed with Encrypt.
+func Decrypt(data []byte, passphrase []byte) (ed25519.PrivateKey, error) {
+	var e encryptedKey
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, errs.New(errs.Config, "invalid encrypted key file: %w", err)
+	}
+	if e.Version != 1 || e.Kdf != "scrypt" || e.Cipher != "xchacha20-poly1305" {
+		return nil, errs.New(errs.Config, "unsupported encrypted key file (version %d, %s, %s)", e.Version, e.Kdf, e.Cipher)
+	}
+	if err := e.check(); err != nil {
+		return nil, err
+	}
+	aead, err := e.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := aead.Open(nil, e.Nonce, e.Ciphertext, []byte(e.Kdf+e.Cipher))
+	if err != nil {
+		return nil, errs.New(errs.Auth, "wrong passphrase or corrupted key file")
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, errs.New(errs.Config, "corrupted key file")
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// check validates the parameters of a file before they are used.
+func (e encryptedKey) check() error {
+	if len(e.Nonce) != chacha20poly1305.NonceSizeX {
+		return errs.New(errs.Config, "corrupted key file: nonce is %d bytes, expected %d", len(e.Nonce), chacha20poly1305.NonceSizeX)
+	}
+	if len(e.Salt) < minSaltSize || len(e.Salt) > maxSaltSize {
+		return errs.New(errs.Config, "corrupted key file: salt is %d bytes", len(e.Salt))
+	}
+	if e.N < 2 || e.N&(e.N-1) != 0 || e.R < 1 || e.P < 1 || e.P > maxScryptP || e.N > maxScryptMem/128/e.R {
+		return errs.New(errs.Config, "corrupted key file: unsupported scrypt parameters n=%d r=%d p=%d", e.N, e.R, e.P)
+	}
+	return nil
+}
+
+func (e encryptedKey) aead(passphrase []byte) (cipher.AEAD, error) {
+	k, err := scrypt.Key(passphrase, e.Salt, e.N, e.R, e.P, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.NewX(k)
+}
+
+/*
+ReadPassphrase returns the value of LEMON3_PASSPHRASE if set,
+otherwise prompts for it on the terminal.
+*/
+func ReadPassphrase() ([]byte, error) {
+	if p, ok := os.LookupEnv(EnvPassphrase); ok {
+		return []byte(p), nil
+	}
+	return prompt("App key passphrase: ")
+}
+
+// ReadNewPassphrase prompts for a new passphrase twice and checks they match.
+func ReadNewPassphrase() ([]byte, error) {
+	if p, ok := os.LookupEnv(EnvPassphrase); ok {
+		return []byte(p), nil
+	}
+	p1, err := prompt("New passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+	if len(p1) == 0 {
+		return nil, errs.New(errs.UserInput, "passphrase can not be empty")
+	}
+	p2, err := prompt("Repeat passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+	if string(p1) != string(p2) {
+		return nil, errs.New(errs.UserInput, "passphrases do not match")
+	}
+	return p1, nil
+}
+
+func prompt(label string) ([]byte, error) {
+	fmt.Fprint(os.Stderr, label)
+	defer fmt.Fprintln(os.Stderr)
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		return term.ReadPassword(fd)
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return nil, err
+	}
+	return []byte(strings.TrimRight(line, "\r\n")), nil
+}
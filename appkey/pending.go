@@ -0,0 +1,108 @@
+package appkey
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/errs"
+)
+
+// Files used while a new app key waits for approval, stored in config.ProfileDir().
+const (
+	PendingFile       = "appkey.pending.enc"
+	SignerRequestFile = "signer-request.json"
+	previousKeySuffix = ".bak"
+)
+
+// SignerRequest describes an app key created by "lemon3 signer new" that is not active yet.
+type SignerRequest struct {
+	PublicKey   string `json:"public_key"`
+	Fid         uint64 `json:"fid"`
+	RequestFid  uint64 `json:"request_fid"`
+	Deadline    int64  `json:"deadline"`
+	Token       string `json:"token,omitempty"`
+	DeeplinkUrl string `json:"deeplink_url,omitempty"`
+}
+
+// configPath returns the path of a file in the directory of the active profile.
+func configPath(name string) (string, error) {
+	dir, err := config.ProfileDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// SavePending stores the encrypted key and the request until the key is activated.
+func SavePending(encrypted []byte, req SignerRequest) error {
+	keyPath, err := configPath(PendingFile)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(keyPath, encrypted, 0600); err != nil {
+		return err
+	}
+	return req.Save()
+}
+
+func (r SignerRequest) Save() error {
+	reqPath, err := configPath(SignerRequestFile)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(reqPath, data, 0600)
+}
+
+// LoadPending returns the pending signer request.
+func LoadPending() (*SignerRequest, error) {
+	reqPath, err := configPath(SignerRequestFile)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(reqPath)
+	if os.IsNotExist(err) {
+		return nil, errs.New(errs.NotFound, "no pending signer, run \"lemon3 signer new\" first")
+	}
+	if err != nil {
+		return nil, err
+	}
+	var r SignerRequest
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, errs.New(errs.Config, "invalid %s: %w", reqPath, err)
+	}
+	return &r, nil
+}
+
+/*
+ActivatePending makes the pending key the encrypted app key. An existing
+encrypted key is kept with a .bak suffix. Returns the path of the new key.
+*/
+func ActivatePending() (string, error) {
+	keyPath, err := configPath(PendingFile)
+	if err != nil {
+		return "", err
+	}
+	target, err := EncryptedPath()
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(target); err == nil {
+		if err := os.Rename(target, target+previousKeySuffix); err != nil {
+			return "", err
+		}
+	}
+	if err := os.Rename(keyPath, target); err != nil {
+		return "", err
+	}
+	reqPath, err := configPath(SignerRequestFile)
+	if err != nil {
+		return "", err
+	}
+	return target, os.Remove(reqPath)
+}
@@ -0,0 +1,191 @@
+package artwork
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+// Image is an encoded image and its dimensions.
+type Image struct {
+	Data   []byte
+	Mime   string
+	Width  int
+	Height int
+}
+
+/*
+Normalize prepares artwork for upload: it applies the EXIF orientation,
+crops the image to a centered square, and returns a cover of at most
+size x size pixels and a thumbnail of at most thumbSize x thumbSize pixels.
+Images are never scaled up.
+
+Both images are re-encoded, so EXIF, GPS and other metadata in the
+original file are not kept. JPEG input produces JPEG, PNG and GIF produce PNG.
+*/
+func Normalize(data []byte, size, thumbSize int) (Image, Image, error) {
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, Image{}, fmt.Errorf("unable to decode artwork: %w", err)
+	}
+	if format == "jpeg" {
+		src = orient(src, jpegOrientation(data))
+	}
+
+	b := src.Bounds()
+	side := min(b.Dx(), b.Dy())
+	square := image.NewRGBA(image.Rect(0, 0, side, side))
+	offset := image.Pt(b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2)
+	draw.Draw(square, square.Bounds(), src, offset, draw.Src)
+
+	cover, err := encode(resize(square, min(side, size)), format)
+	if err != nil {
+		return Image{}, Image{}, err
+	}
+	thumb, err := encode(resize(square, min(side, thumbSize)), format)
+	if err != nil {
+		return Image{}, Image{}, err
+	}
+	return cover, thumb, nil
+}
+
+func encode(img *image.RGBA, format string) (Image, error) {
+	var buf bytes.Buffer
+	out := Image{Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+	if format == "jpeg" {
+		out.Mime = "image/jpeg"
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+			return Image{}, err
+		}
+	} else {
+		out.Mime = "image/png"
+		if err := png.Encode(&buf, img); err != nil {
+			return Image{}, err
+		}
+	}
+	out.Data = buf.Bytes()
+	return out, nil
+}
+
+// resize scales the square image src to side x side pixels, averaging the
+// source pixels that each destination pixel covers.
+func resize(src *image.RGBA, side int) *image.RGBA {
+	n := src.Bounds().Dx()
+	if side <= 0 || side >= n {
+		return src
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, side, side))
+	for y := range side {
+		y0, y1 := y*n/side, max((y+1)*n/side, y*n/side+1)
+		for x := range side {
+			x0, x1 := x*n/side, max((x+1)*n/side, x*n/side+1)
+			var r, g, b, a, count int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += int(p[0])
+					g += int(p[1])
+					b += int(p[2])
+					a += int(p[3])
+					count++
+				}
+			}
+			p := dst.Pix[y*dst.Stride+x*4:]
+			p[0], p[1], p[2], p[3] = uint8(r/count), uint8(g/count), uint8(b/count), uint8(a/count)
+		}
+	}
+	return dst
+}
+
+// orient rotates and flips img according to an EXIF orientation value (1-8).
+func orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 { // rotated by 90 degrees
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := range dh {
+		for x := range dw {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored
+				sx, sy = w-1-x, y
+			case 3: // rotated 180
+				sx, sy = w-1-x, h-1-y
+			case 4: // flipped
+				sx, sy = x, h-1-y
+			case 5: // transposed
+				sx, sy = y, x
+			case 6: // rotated 90 clockwise
+				sx, sy = y, h-1-x
+			case 7: // transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // rotated 90 counter-clockwise
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, img.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// jpegOrientation returns the EXIF orientation of a JPEG file, or 1 if it has none.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return 1
+	}
+	data = data[2:]
+	for len(data) >= 4 && data[0] == 0xff && data[1] != 0xda {
+		n := int(binary.BigEndian.Uint16(data[2:]))
+		if n < 2 || 2+n > len(data) {
+			return 1
+		}
+		segment := data[4 : 2+n]
+		if data[1] == 0xe1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+		data = data[2+n:]
+	}
+	return 1
+}
+
+// tiffOrientation reads the Orientation tag (0x0112) from the first IFD of TIFF data.
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 0 || ifd+2 > len(tiff) {
+		return 1
+	}
+	for i := range int(order.Uint16(tiff[ifd:])) {
+		entry := ifd + 2 + 12*i
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 && order.Uint16(tiff[entry+2:]) == 3 { // SHORT
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 1
+}
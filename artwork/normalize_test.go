@@ -0,0 +1,82 @@
+package artwork
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// exifJPEG encodes img as a JPEG with an EXIF segment holding the orientation.
+func exifJPEG(t *testing.T, img image.Image, orientation uint16) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	tiff := []byte("MM\x00*\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3) // SHORT
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+
+	data := []byte{0xff, 0xd8, 0xff, 0xe1}
+	data = binary.BigEndian.AppendUint16(data, uint16(len(segment)+2))
+	data = append(data, segment...)
+	return append(data, buf.Bytes()[2:]...)
+}
+
+func TestNormalize(t *testing.T) {
+	// 400x200, left half red, right half blue.
+	img := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for y := range 200 {
+		for x := range 400 {
+			c := color.RGBA{0xff, 0, 0, 0xff}
+			if x >= 200 {
+				c = color.RGBA{0, 0, 0xff, 0xff}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	data := exifJPEG(t, img, 6) // rotate 90 clockwise: 200x400, red at the top
+	if o := jpegOrientation(data); o != 6 {
+		t.Fatalf("orientation = %d, expected 6", o)
+	}
+
+	cover, thumb, err := Normalize(data, 1024, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cover.Mime != "image/jpeg" || cover.Width != 200 || cover.Height != 200 {
+		t.Errorf("unexpected cover %s %dx%d", cover.Mime, cover.Width, cover.Height)
+	}
+	if thumb.Width != 50 || thumb.Height != 50 {
+		t.Errorf("unexpected thumbnail %dx%d", thumb.Width, thumb.Height)
+	}
+	if bytes.Contains(cover.Data, []byte("Exif")) || bytes.Contains(thumb.Data, []byte("Exif")) {
+		t.Error("EXIF data was not removed")
+	}
+
+	// The centered square of the rotated image is red at the top and blue at the bottom.
+	decoded, err := jpeg.Decode(bytes.NewReader(cover.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, _, b, _ := decoded.At(100, 10).RGBA(); r>>8 < 0xc0 || b>>8 > 0x40 {
+		t.Errorf("expected red at the top, got r=%d b=%d", r>>8, b>>8)
+	}
+	if r, _, b, _ := decoded.At(100, 190).RGBA(); b>>8 < 0xc0 || r>>8 > 0x40 {
+		t.Errorf("expected blue at the bottom, got r=%d b=%d", r>>8, b>>8)
+	}
+}
+
+func TestNormalizeInvalid(t *testing.T) {
+	if _, _, err := Normalize([]byte("not an image"), 1024, 256); err == nil {
+		t.Error("expected an error")
+	}
+}
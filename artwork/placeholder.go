@@ -0,0 +1,189 @@
+/*
+Package artwork prepares the images uploaded with lemon3 files.
+*/
+package artwork
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"path/filepath"
+	"strings"
+
+	"github.com/vrypan/lemon3/lemon3libs"
+)
+
+// PlaceholderSize is the width and height of the generated placeholder art.
+const PlaceholderSize = 600
+
+const margin = 48
+
+// Card describes the file a placeholder is generated for.
+type Card struct {
+	Title    string
+	Filename string
+	Type     string // MIME type
+	Size     int64
+}
+
+var (
+	white  = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	light  = color.RGBA{0xff, 0xff, 0xff, 0xb0}
+	lemon  = color.RGBA{0xf5, 0xd0, 0x00, 0xff}
+	shadow = color.RGBA{0x00, 0x00, 0x00, 0x40}
+)
+
+// backgrounds are the card colors for each kind of file.
+var backgrounds = map[string]color.RGBA{
+	"audio": {0x1b, 0x4d, 0x3e, 0xff},
+	"video": {0x4a, 0x1e, 0x6e, 0xff},
+	"image": {0x1d, 0x3f, 0x6e, 0xff},
+	"text":  {0x5a, 0x46, 0x3a, 0xff},
+	"file":  {0x3a, 0x3a, 0x3a, 0xff},
+}
+
+// kind returns the family of a MIME type: audio, video, image, text or file.
+func kind(mimeType string) string {
+	family, _, _ := strings.Cut(mimeType, "/")
+	if _, ok := backgrounds[family]; ok {
+		return family
+	}
+	return "file"
+}
+
+/*
+Placeholder renders a PNG card with the title, file name, type and size of
+a file, used as artwork when the uploader does not provide one.
+
+Text is drawn with a built-in bitmap font that only covers ASCII,
+other characters are shown as '?'.
+*/
+func Placeholder(c Card) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, PlaceholderSize, PlaceholderSize))
+	family := kind(c.Type)
+	fill(img, img.Bounds(), backgrounds[family])
+
+	// Type icon: a page with a folded corner and the file extension.
+	ext := strings.ToUpper(strings.TrimPrefix(filepath.Ext(c.Filename), "."))
+	if len(ext) > 4 || ext == "" {
+		ext = strings.ToUpper(family)[:min(4, len(family))]
+	}
+	icon := image.Rect(margin, margin, margin+120, margin+150)
+	fill(img, icon.Add(image.Pt(6, 6)), shadow)
+	fill(img, icon, lemon)
+	for i := range 32 { // folded corner
+		fill(img, image.Rect(icon.Max.X-32+i, icon.Min.Y, icon.Max.X, icon.Min.Y+32-i), backgrounds[family])
+	}
+	fill(img, image.Rect(icon.Max.X-32, icon.Min.Y, icon.Max.X-31, icon.Min.Y+32), shadow)
+	scale := 4
+	for textWidth(ext, scale) > icon.Dx()-16 {
+		scale--
+	}
+	drawText(img, icon.Min.X+(icon.Dx()-textWidth(ext, scale))/2, icon.Max.Y-48, ext, scale, backgrounds[family])
+
+	drawText(img, icon.Max.X+32, icon.Min.Y+16, strings.ToUpper(family), 3, light)
+	if c.Type != "" {
+		drawText(img, icon.Max.X+32, icon.Min.Y+56, truncate(c.Type, (PlaceholderSize-icon.Max.X-32-margin)/(6*2)), 2, light)
+	}
+	if c.Size > 0 {
+		drawText(img, icon.Max.X+32, icon.Min.Y+88, lemon3libs.HumanSize(c.Size), 2, light)
+	}
+
+	title := c.Title
+	if title == "" {
+		title = c.Filename
+	}
+	y := icon.Max.Y + 56
+	for _, line := range wrap(title, (PlaceholderSize-2*margin)/(6*4), 5) {
+		drawText(img, margin, y, line, 4, white)
+		y += 44
+	}
+
+	if c.Filename != "" && c.Filename != title {
+		drawText(img, margin, PlaceholderSize-margin-16, truncate(c.Filename, (PlaceholderSize-2*margin)/(6*2)), 2, light)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func fill(img draw.Image, r image.Rectangle, c color.Color) {
+	draw.Draw(img, r, image.NewUniform(c), image.Point{}, draw.Over)
+}
+
+// textWidth returns the width in pixels of s drawn at scale.
+func textWidth(s string, scale int) int {
+	n := len([]rune(s))
+	if n == 0 {
+		return 0
+	}
+	return (6*n - 1) * scale
+}
+
+// drawText draws s with its top left corner at x, y. Each font pixel is a scale x scale square.
+func drawText(img draw.Image, x, y int, s string, scale int, c color.Color) {
+	for _, r := range s {
+		for col, bits := range glyph(r) {
+			for row := range 8 {
+				if bits&(1<<row) != 0 {
+					px := x + col*scale
+					py := y + row*scale
+					fill(img, image.Rect(px, py, px+scale, py+scale), c)
+				}
+			}
+		}
+		x += 6 * scale
+	}
+}
+
+// truncate shortens s to at most n characters, ending it with "..." if it was cut.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	if n <= 3 {
+		return string(r[:n])
+	}
+	return strings.TrimRight(string(r[:n-3]), " ") + "..."
+}
+
+// wrap breaks s into at most maxLines lines of up to width characters,
+// splitting long words and truncating the last line if needed.
+func wrap(s string, width, maxLines int) []string {
+	lines := []string{}
+	line := ""
+	for _, word := range strings.Fields(s) {
+		for len([]rune(word)) > width {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			r := []rune(word)
+			lines = append(lines, string(r[:width]))
+			word = string(r[width:])
+		}
+		switch {
+		case line == "":
+			line = word
+		case len([]rune(line))+1+len([]rune(word)) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	if len(lines) > maxLines {
+		last := strings.Join(lines[maxLines-1:], " ")
+		lines = append(lines[:maxLines-1], truncate(last, width))
+	}
+	return lines
+}
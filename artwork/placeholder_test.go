@@ -0,0 +1,44 @@
+package artwork
+
+import (
+	"bytes"
+	"image/png"
+	"reflect"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		s        string
+		width    int
+		maxLines int
+		expected []string
+	}{
+		{"Answers for Shel Israel", 10, 5, []string{"Answers", "for Shel", "Israel"}},
+		{"abcdefghijkl", 5, 5, []string{"abcde", "fghij", "kl"}},
+		{"one two three four", 9, 2, []string{"one two", "three..."}},
+		{"", 10, 3, []string{}},
+	}
+	for _, test := range tests {
+		if lines := wrap(test.s, test.width, test.maxLines); !reflect.DeepEqual(lines, test.expected) {
+			t.Errorf("wrap(%q, %d, %d) = %q, expected %q", test.s, test.width, test.maxLines, lines, test.expected)
+		}
+	}
+}
+
+func TestPlaceholder(t *testing.T) {
+	data, err := Placeholder(Card{Title: "Plan 9 from Outer Space", Filename: "plan9.mp4", Type: "video/mp4", Size: 773495003})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != PlaceholderSize || b.Dy() != PlaceholderSize {
+		t.Errorf("placeholder is %dx%d", b.Dx(), b.Dy())
+	}
+	if r, g, b, _ := img.At(0, 0).RGBA(); r>>8 != 0x4a || g>>8 != 0x1e || b>>8 != 0x6e {
+		t.Errorf("unexpected background color for video")
+	}
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/errs"
+	"github.com/vrypan/lemon3/ipfsclient"
+	"github.com/vrypan/lemon3/lemon3libs"
+	"github.com/vrypan/lemon3/output"
+)
+
+var castCmd = &cobra.Command{
+	Use:   "cast --cid <metadata-cid>",
+	Short: "Post a new cast for a file that is already uploaded",
+	Long: `Post a new cast with the lemon3 embeds of a file that is already
+uploaded, for example to share it again in another channel.
+
+The metadata is checked, and the metadata, the file and its artwork are
+pinned and announced from the local node before the cast is posted.
+Nothing is uploaded again.
+To cast a file from someone else's cast, see "lemon3 recast".`,
+	Example: `lemon3 cast --cid bafyrei... --channel music --text "Still one of my favourites"`,
+	Args:    cobra.NoArgs,
+	RunE:    castCid,
+}
+
+func castCid(cmd *cobra.Command, args []string) error {
+	configFile := config.Load()
+	if configFile == "" {
+		return errNoSetup
+	}
+	cid, _ := cmd.Flags().GetString("cid")
+	if cid == "" {
+		return cmd.Help()
+	}
+	if !lemon3libs.IsCid(cid) {
+		return errs.New(errs.UserInput, "%s is not a CID", cid)
+	}
+	return castExisting(cmd, cid, true)
+}
+
+/*
+castExisting posts a cast for the lemon3 metadata cid, with the text,
+channel and preview flags of cmd. The metadata must be valid. If pin is
+set, the metadata, the enclosed file and the artwork are pinned on the
+local node and announced first.
+*/
+func castExisting(cmd *cobra.Command, cid string, pin bool) error {
+	pub, err := publisherFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+	channel, _ := cmd.Flags().GetString("channel")
+	parentUrl, err := pub.channelUrl(channel)
+	if err != nil {
+		return err
+	}
+	if err := ipfsclient.Init(config.GetString("ipfs.hub")); err != nil {
+		return err
+	}
+
+	meta, err := lemon3libs.FromCid(cid)
+	if err != nil {
+		return err
+	}
+	if err := meta.Validate(); err != nil {
+		return fmt.Errorf("invalid metadata %s: %w", cid, err)
+	}
+	output.Status("metadata", output.Fields{"cid": cid, "title": meta.Title}, "[+] %s: %s (%s)", cid, meta.Title, meta.Filename)
+
+	if pin {
+		output.Status("pin", output.Fields{"cid": cid}, "[^] Pinning %s and %s (%s)", cid, meta.Enclosed["/"], lemon3libs.HumanSize(meta.Size))
+		if err := pinFile(cid, meta); err != nil {
+			return err
+		}
+		// The metadata does not link to the other CIDs, each one is announced.
+		for _, p := range filePins(cid, meta) {
+			if err := ipfsclient.ProvideCIDRecursive(p.Cid); err != nil {
+				return fmt.Errorf("failed to announce %s: %w", p.Role, err)
+			}
+			output.Status("pinned", output.Fields{"cid": p.Cid, "role": p.Role}, "[+] %s pinned.", p.Cid)
+		}
+	}
+
+	text, _ := cmd.Flags().GetString("text")
+	up := uploadResult{Title: meta.Title, Filename: meta.Filename, Metadata: cid, Enclosed: meta.Enclosed["/"]}
+	castHash, err := pub.cast(text, up, parentUrl)
+	if err != nil {
+		return err
+	}
+	output.Result(
+		output.Fields{
+			"cast":     fmt.Sprintf("@%s/0x%s", pub.username, castHash),
+			"hash":     "0x" + castHash,
+			"url":      fmt.Sprintf("https://farcaster.xyz/%s/0x%s", pub.username, castHash),
+			"metadata": cid,
+			"enclosed": up.Enclosed,
+		},
+		"\nView cast: https://farcaster.xyz/%s/0x%s", pub.username, castHash,
+	)
+	return nil
+}
+
+// addCastFlags adds the flags of castExisting to cmd.
+func addCastFlags(cmd *cobra.Command) {
+	cmd.Flags().String("text", "", "Cast text")
+	cmd.Flags().String("channel", "", "Post the cast in this channel (channel id, or parent URL)")
+	cmd.Flags().StringSlice("preview", nil, "Preview URL template, {cid}, {filename} and {fid} are expanded (overrides preview.urls)")
+	cmd.Flags().Bool("no-preview", false, "Do not add a preview URL to the cast")
+}
+
+func init() {
+	rootCmd.AddCommand(castCmd)
+	castCmd.Flags().String("cid", "", "lemon3 metadata CID")
+	addCastFlags(castCmd)
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	pb "github.com/vrypan/farcaster-go/farcaster"
+	"github.com/vrypan/lemon3/errs"
+	"github.com/vrypan/lemon3/fcclient"
+	"github.com/vrypan/lemon3/lemon3libs"
+)
+
+/*
+resolveCastRef fetches the cast ref points to, and returns it with the
+lemon3 metadata CID it embeds. If ref is a metadata CID, the cast is nil.
+*/
+func resolveCastRef(hub *fcclient.FarcasterHub, ref lemon3libs.CastRef) (*pb.Message, string, error) {
+	if ref.Cid != "" {
+		return nil, ref.Cid, nil
+	}
+	fid := ref.Fid
+	if fid == 0 {
+		var err error
+		if fid, err = hub.GetFidByUsername(ref.Fname); err != nil {
+			return nil, "", fmt.Errorf("unable to get FID for @%s: %w", ref.Fname, err)
+		}
+	}
+
+	var cast *pb.Message
+	if ref.IsShort() {
+		var err error
+		if cast, err = findCastByPrefix(hub, fid, ref); err != nil {
+			return nil, "", err
+		}
+	} else {
+		hash, _ := hex.DecodeString(strings.TrimPrefix(ref.Hash, "0x")) // validated by ParseCastRef
+		var err error
+		if cast, err = hub.GetCast(fid, hash); err != nil {
+			return nil, "", fmt.Errorf("failed to get cast %s: %w", ref, err)
+		}
+	}
+	cid := lemon3libs.CidFromMessage(cast)
+	if cid == "" {
+		return cast, "", errs.New(errs.Verification, "cast %s is not a lemon3 cast", ref)
+	}
+	return cast, cid, nil
+}
+
+// resolveOwnCast is resolveCastRef for casts of the account of pub, the ones lemon3 can change.
+func resolveOwnCast(pub *publisher, ref lemon3libs.CastRef) (*pb.Message, string, error) {
+	if ref.Cid != "" {
+		return nil, "", errs.New(errs.UserInput, "%s is a CID, use a cast like @%s/0xhash", ref, pub.username)
+	}
+	hub, err := fcclient.NewFarcasterHub(pub.hubConf)
+	if err != nil {
+		return nil, "", err
+	}
+	defer hub.Close()
+	cast, cid, err := resolveCastRef(hub, ref)
+	if err != nil {
+		return nil, "", err
+	}
+	fid, err := hub.GetFidByUsername(pub.username)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to get FID for @%s: %w", pub.username, err)
+	}
+	if cast.Data.Fid != fid {
+		return nil, "", errs.New(errs.UserInput, "%s is not a cast of @%s", ref, pub.username)
+	}
+	return cast, cid, nil
+}
+
+/*
+findCastByPrefix scans the casts of fid for a hash starting with ref.Hash.
+Prefixes as long as the ones in Farcaster URLs (8 digits) return the first
+match; shorter ones must match a single cast.
+*/
+func findCastByPrefix(hub *fcclient.FarcasterHub, fid uint64, ref lemon3libs.CastRef) (*pb.Message, error) {
+	firstMatch := len(ref.Hash)-2 >= 8
+	var found *pb.Message
+	var pageToken []byte
+	for {
+		page, err := hub.GetCastsByFidPage(fid, 100, true, pageToken)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get casts: %w", err)
+		}
+		for _, msg := range page.Messages {
+			if !ref.MatchHash(msg.Hash) {
+				continue
+			}
+			if found != nil {
+				return nil, errs.New(errs.UserInput, "%s matches more than one cast, use a longer hash", ref)
+			}
+			found = msg
+			if firstMatch {
+				return found, nil
+			}
+		}
+		if len(page.NextPageToken) == 0 || len(page.Messages) == 0 {
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+	if found == nil {
+		return nil, errs.New(errs.NotFound, "no cast matches %s", ref)
+	}
+	return found, nil
+}
@@ -2,48 +2,73 @@ package cmd
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/output"
 )
 
 var configlsCmd = &cobra.Command{
 	Use:   "ls",
 	Short: "Get a list of all parameters and their values",
-	Run:   config_ls,
+	RunE:  config_ls,
 }
 
-func traverse(parent string, data map[string]interface{}) {
+// flatten collects the values of nested settings as "parent.key" entries.
+func flatten(parent string, data map[string]interface{}, out map[string]interface{}) {
 	for key, value := range data {
 		prefix := parent + "."
 		if parent == "" {
 			prefix = ""
 		}
 		switch v := value.(type) {
-		case string:
-			fmt.Printf("%s: %s\n", prefix+key, v)
-		case int:
-			fmt.Printf("%s: %d\n", prefix+key, v)
-		case []interface{}:
-			fmt.Printf("%s:", prefix+key)
-			for _, item := range v {
-				fmt.Printf(" %v", item)
-			}
-			fmt.Printf("\n")
 		case map[string]interface{}:
-			traverse(prefix+key, v) // Recursive call to traverse nested map
+			flatten(prefix+key, v, out) // Recursive call to traverse nested map
 		default:
-			panic("Unknown value")
+			out[prefix+key] = v
 		}
 	}
 }
-func config_ls(cmd *cobra.Command, args []string) {
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case []string:
+		return strings.Join(v, " ")
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = fmt.Sprintf("%v", item)
+		}
+		return strings.Join(items, " ")
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func config_ls(cmd *cobra.Command, args []string) error {
 	config.Load()
-	settings := viper.AllSettings()
-	traverse("", settings)
+	settings := map[string]interface{}{}
+	flatten("", viper.AllSettings(), settings)
 
+	keys := make([]string, 0, len(settings))
+	for k := range settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	lines := make([]string, len(keys))
+	for i, k := range keys {
+		lines[i] = fmt.Sprintf("%s: %s", k, formatValue(settings[k]))
+	}
+	output.Result(
+		output.Fields{"profile": config.ActiveProfile(), "config": settings},
+		"%s", strings.Join(lines, "\n"),
+	)
+	return nil
 }
+
 func init() {
 	configCmd.AddCommand(configlsCmd)
 }
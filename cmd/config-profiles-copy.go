@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/config"
+)
+
+var configprofilescopyCmd = &cobra.Command{
+	Use:   "copy <source> <destination>",
+	Short: "Create a profile from an existing one",
+	Long: `Create a profile with the configuration of an existing one.
+
+The encrypted app key is not copied. Example:
+lemon3 config profiles copy default work
+lemon3 --profile work config set farcaster.account.fname team`,
+	Args: cobra.ExactArgs(2),
+	RunE: config_profiles_copy,
+}
+
+func config_profiles_copy(cmd *cobra.Command, args []string) error {
+	if err := config.CopyProfile(args[0], args[1]); err != nil {
+		return err
+	}
+	fmt.Printf("Created profile %s.\n", args[1])
+	return nil
+}
+
+func init() {
+	configprofilesCmd.AddCommand(configprofilescopyCmd)
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/output"
+)
+
+var configprofileslsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List profiles",
+	RunE:  config_profiles_ls,
+}
+
+func config_profiles_ls(cmd *cobra.Command, args []string) error {
+	profiles, err := config.Profiles()
+	if err != nil {
+		return err
+	}
+	active := config.ActiveProfile()
+	lines := make([]string, len(profiles))
+	for i, p := range profiles {
+		if p == active {
+			lines[i] = "* " + p
+		} else {
+			lines[i] = "  " + p
+		}
+	}
+	output.Result(
+		output.Fields{"active": active, "profiles": profiles},
+		"%s", strings.Join(lines, "\n"),
+	)
+	return nil
+}
+
+func init() {
+	configprofilesCmd.AddCommand(configprofileslsCmd)
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/config"
+)
+
+var configprofilesuseCmd = &cobra.Command{
+	Use:   "use <profile>",
+	Short: "Set the default profile",
+	Args:  cobra.ExactArgs(1),
+	RunE:  config_profiles_use,
+}
+
+func config_profiles_use(cmd *cobra.Command, args []string) error {
+	if err := config.UseProfile(args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("Using profile %s.\n", args[0])
+	return nil
+}
+
+func init() {
+	configprofilesCmd.AddCommand(configprofilesuseCmd)
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var configprofilesCmd = &cobra.Command{
+	Use:     "profiles",
+	Aliases: []string{"profile"},
+	Short:   "Manage configuration profiles",
+	Long: `Profiles let you keep separate configurations, for example for
+a personal and a team account using different hubs and IPFS nodes.
+
+Use --profile or LEMON3_PROFILE to select a profile for one command,
+or "lemon3 config profiles use" to change the default.
+
+Create a profile with "lemon3 setup --profile <name>", or by copying
+an existing one.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configprofilesCmd)
+}
@@ -1,7 +1,10 @@
 package cmd
 
 import (
-	"log"
+	"strings"
+
+	"github.com/vrypan/lemon3/errs"
+	"github.com/vrypan/lemon3/fcclient"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -14,16 +17,25 @@ var configsetCmd = &cobra.Command{
 	Long: `Example:
 lemon3 config set ipfs.hub http://192.168.1.10:5001/api/v0
 `,
-	Run: config_set,
+	RunE: config_set,
 }
 
-func config_set(cmd *cobra.Command, args []string) {
+func config_set(cmd *cobra.Command, args []string) error {
 	config.Load()
 	if len(args) != 2 {
-		log.Fatal("Wrong number of arguments")
+		return errs.New(errs.UserInput, "wrong number of arguments")
+	}
+	// Lists are stored as strings, and split on spaces when they are read.
+	if args[0] == "preview.urls" {
+		if err := fcclient.CheckPreviewUrls(strings.Fields(args[1])); err != nil {
+			return errs.New(errs.UserInput, "preview.urls: %w", err)
+		}
 	}
 	viper.Set(args[0], args[1])
-	viper.WriteConfig()
+	if err := viper.WriteConfig(); err != nil {
+		return errs.New(errs.Config, "failed to write config: %w", err)
+	}
+	return nil
 }
 func init() {
 	configCmd.AddCommand(configsetCmd)
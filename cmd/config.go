@@ -14,7 +14,7 @@ var configCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		cmd.Help()
 		f := config.Load()
-		fmt.Printf("\nConfig file is %s\n", f)
+		fmt.Printf("\nConfig file is %s (profile %s)\n", f, config.ActiveProfile())
 	},
 }
 
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/errs"
+	"github.com/vrypan/lemon3/output"
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Keep posting scheduled casts when they are due",
+	Long: `Runs "lemon3 queue run" every --interval, until it is stopped.
+
+On SIGINT or SIGTERM, the cast being posted is finished before exiting.
+
+App keys are loaded once, so set LEMON3_PASSPHRASE or use
+farcaster.account.appkey_cmd if the daemon runs unattended.`,
+	RunE: daemon,
+}
+
+func daemon(cmd *cobra.Command, args []string) error {
+	interval, _ := cmd.Flags().GetDuration("interval")
+	if interval < time.Second {
+		return errs.New(errs.UserInput, "--interval must be at least 1s")
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	stop := make(chan struct{})
+	go func() {
+		<-signals
+		signal.Stop(signals) // a second signal stops the daemon at once
+		close(stop)
+	}()
+
+	runner := &queueRunner{publishers: map[string]*publisher{}, stop: stop}
+	output.Status("daemon", output.Fields{"interval": interval.String()}, "[+] Checking the queue every %s", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := runner.run(); err != nil {
+			output.Status("daemon", output.Fields{"error": err.Error()}, "[!] %v", err)
+		}
+		select {
+		case <-stop:
+			output.Status("daemon", output.Fields{"event": "stopped"}, "[-] Stopped")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.Flags().Duration("interval", time.Minute, "How often to check for due casts")
+}
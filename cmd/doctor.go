@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/appkey"
+	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/fcclient"
+	"github.com/vrypan/lemon3/ipfsclient"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the configuration, the Farcaster and IPFS nodes, and the app key",
+	RunE:  doctor,
+}
+
+// doctorReport prints the result of each check and remembers failures.
+type doctorReport struct {
+	failed int
+}
+
+func (r *doctorReport) ok(format string, a ...any) {
+	fmt.Printf("[✓] "+format+"\n", a...)
+}
+
+func (r *doctorReport) warn(remedy string, format string, a ...any) {
+	fmt.Printf("[!] "+format+"\n", a...)
+	if remedy != "" {
+		fmt.Printf("    → %s\n", remedy)
+	}
+}
+
+func (r *doctorReport) fail(remedy string, format string, a ...any) {
+	r.failed++
+	fmt.Printf("[×] "+format+"\n", a...)
+	if remedy != "" {
+		fmt.Printf("    → %s\n", remedy)
+	}
+}
+
+func (r *doctorReport) skip(name string, reason string) {
+	fmt.Printf("[-] %s: skipped, %s\n", name, reason)
+}
+
+func doctor(cmd *cobra.Command, args []string) error {
+	configFile := config.Load()
+	r := &doctorReport{}
+
+	// Configuration
+	if _, err := os.Stat(configFile); err != nil {
+		r.fail("Run \"lemon3 setup\".", "Config file %s (profile %s) not found", configFile, config.ActiveProfile())
+	} else {
+		r.ok("Config file %s (profile %s)", configFile, config.ActiveProfile())
+	}
+	configOk := true
+	for _, entry := range setupEntries() {
+		if err := entry.check(config.GetString(entry.Key)); err != nil {
+			configOk = false
+			r.fail(fmt.Sprintf("Run \"lemon3 config set %s <value>\" or \"lemon3 setup\".", entry.Key), "%s: %v", entry.Key, err)
+		}
+	}
+	if configOk {
+		r.ok("Configuration values are valid")
+	}
+
+	// Farcaster node
+	hubOk := false
+	var fid uint64
+	username := config.GetString("farcaster.account.fname")
+	if validateHostPort(config.GetString("farcaster.node.address")) != nil {
+		r.skip("Farcaster node", "farcaster.node.address is not valid")
+	} else if hub, err := fcclient.NewFarcasterHub(hubConfig()); err != nil {
+		r.fail("Check farcaster.node.address.", "Farcaster node %s: %v", config.GetString("farcaster.node.address"), err)
+	} else {
+		defer hub.Close()
+		info, err := hub.GetInfo()
+		if err != nil {
+			remedy := "Check farcaster.node.address, and that the node is running."
+			if config.GetString("farcaster.node.ssl") != "true" && strings.Contains(err.Error(), "connection") {
+				remedy += " Hosted nodes usually need farcaster.node.ssl=true."
+			}
+			r.fail(remedy, "Farcaster node %s: %v", config.GetString("farcaster.node.address"), err)
+		} else {
+			hubOk = true
+			r.ok("Farcaster node %s, version %s", config.GetString("farcaster.node.address"), info.Version)
+		}
+
+		if hubOk {
+			fid, err = hub.GetFidByUsername(username)
+			switch code := status.Code(err); {
+			case err == nil:
+				if config.GetString("farcaster.node.apikey") != "" {
+					r.ok("API key accepted")
+				}
+				r.ok("@%s is FID %d", username, fid)
+			case code == codes.Unauthenticated || code == codes.PermissionDenied:
+				r.fail("Check farcaster.node.apikey.", "API key rejected: %v", err)
+			default:
+				r.fail("Check farcaster.account.fname.", "Unable to get FID for @%s: %v", username, err)
+			}
+		}
+
+		if fid != 0 {
+			source, err := appkey.FromConfig()
+			if err != nil {
+				r.fail("Run \"lemon3 signer new\" or \"lemon3 key import\".", "App key: %v", err)
+			} else if key, err := source.Load(); err != nil {
+				r.fail("Check the passphrase, farcaster.account.appkey_cmd or farcaster.account.appkey.", "App key: %v", err)
+			} else if _, err := fcclient.CheckSigner(hubConfig(), username, key); err != nil {
+				r.fail("Create a new key with \"lemon3 signer new\", or approve this one.", "%v", err)
+			} else {
+				r.ok("App key %s is an active signer", appkey.PublicHex(key))
+			}
+		} else {
+			r.skip("App key", "FID is unknown")
+		}
+	}
+
+	// IPFS node
+	if err := ipfsclient.Ping(config.GetString("ipfs.hub")); err != nil {
+		r.fail("Check ipfs.hub, and that Kubo (or IPFS Desktop) is running.", "IPFS node %s: %v", config.GetString("ipfs.hub"), err)
+	} else {
+		ipfsclient.Init(config.GetString("ipfs.hub")) // Ping succeeded, so this does not fail
+		version, _ := ipfsclient.Version()
+		id, err := ipfsclient.Id()
+		if err != nil {
+			r.fail("", "IPFS node %s: %v", config.GetString("ipfs.hub"), err)
+		} else {
+			r.ok("IPFS node %s, Kubo %s, peer %s", config.GetString("ipfs.hub"), version, id.ID)
+		}
+
+		peers, err := ipfsclient.SwarmPeerCount()
+		switch {
+		case err != nil:
+			r.fail("", "Swarm peers: %v", err)
+		case peers == 0:
+			r.fail("Check the network connection and firewall of the IPFS node.", "IPFS node is not connected to any peers")
+		case peers < 10:
+			r.warn("Other nodes may have trouble finding your content. Check the firewall of the IPFS node.", "IPFS node is connected to %d peers", peers)
+		default:
+			r.ok("IPFS node is connected to %d peers", peers)
+		}
+
+		if id != nil {
+			public := ipfsclient.PublicAddresses(id)
+			switch {
+			case len(public) > 0:
+				r.ok("IPFS node is publicly dialable (%s)", public[0])
+			case ipfsclient.IsRelayed(id):
+				r.warn("Forward port 4001 (TCP and UDP) to the IPFS node for faster transfers.", "IPFS node is only reachable through relays")
+			default:
+				r.fail("Forward port 4001 (TCP and UDP) to the IPFS node, or enable UPnP. Others will not be able to download your uploads.", "IPFS node is not publicly dialable")
+			}
+		}
+	}
+
+	// Download directory
+	dir := config.GetString("download.dir")
+	if dir == "" {
+		r.skip("Download directory", "download.dir is not set")
+	} else if _, err := os.Stat(dir); os.IsNotExist(err) {
+		r.warn("It is created by the first download, or set download.dir to an existing directory.", "Download directory %s does not exist", dir)
+	} else if err := checkWritable(dir); err != nil {
+		r.fail("Set download.dir to a writable directory.", "Download directory %s: %v", dir, err)
+	} else {
+		r.ok("Download directory %s is writable", dir)
+	}
+
+	fmt.Println()
+	if r.failed > 0 {
+		return fmt.Errorf("%d check(s) failed", r.failed)
+	}
+	fmt.Println("Everything looks good.")
+	return nil
+}
+
+// checkWritable checks that files can be written in dir.
+func checkWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".lemon3-doctor-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/id3"
+	"github.com/vrypan/lemon3/ipfsclient"
+	"github.com/vrypan/lemon3/lemon3libs"
+	"github.com/vrypan/lemon3/output"
+)
+
+// downloadExtras selects the files saved next to a downloaded file.
+type downloadExtras struct {
+	artwork bool
+	sidecar bool
+	nfo     bool
+	id3     bool
+}
+
+func addExtrasFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("artwork", false, "Save the artwork next to the file")
+	cmd.Flags().Bool("sidecar", false, "Save the cast and metadata in <file>.lemon3.json")
+	cmd.Flags().Bool("nfo", false, "Save a Kodi/Jellyfin .nfo file for videos, not for audio or other files (implies --artwork)")
+	cmd.Flags().Bool("id3", false, "Write the title, description, publisher, date and artwork to the ID3 tag of MP3 files")
+}
+
+func extrasFromFlags(cmd *cobra.Command) downloadExtras {
+	x := downloadExtras{}
+	x.artwork, _ = cmd.Flags().GetBool("artwork")
+	x.sidecar, _ = cmd.Flags().GetBool("sidecar")
+	x.nfo, _ = cmd.Flags().GetBool("nfo")
+	x.id3, _ = cmd.Flags().GetBool("id3")
+	x.artwork = x.artwork || x.nfo
+	return x
+}
+
+/*
+save writes the extras of the file downloaded to path, and returns the
+paths it wrote. The artwork is saved as cover.<ext> when the file is
+downloaded on its own (single), and as <file>-poster.<ext> otherwise,
+the names Kodi and Jellyfin look for. cast can be nil.
+*/
+func (x downloadExtras) save(path string, cid string, cast *lemon3libs.L3Cast, meta *lemon3libs.Lemon3Metadata, single bool) ([]string, error) {
+	dir := filepath.Dir(path)
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	saved := []string{}
+
+	tagMP3 := x.id3 && (meta.Type == "audio/mpeg" || strings.EqualFold(filepath.Ext(path), ".mp3"))
+	var artwork []byte
+	if (x.artwork || tagMP3) && meta.Artwork["/"] != "" {
+		var err error
+		if artwork, err = ipfsclient.CatCID(meta.Artwork["/"]); err != nil {
+			return saved, fmt.Errorf("failed to download artwork: %w", err)
+		}
+	}
+
+	poster := ""
+	if x.artwork && artwork != nil {
+		ext := ".jpg"
+		switch http.DetectContentType(artwork) {
+		case "image/png":
+			ext = ".png"
+		case "image/gif":
+			ext = ".gif"
+		case "image/webp":
+			ext = ".webp"
+		}
+		poster = "cover" + ext
+		if !single {
+			poster = base + "-poster" + ext
+		}
+		if err := os.WriteFile(filepath.Join(dir, poster), artwork, 0644); err != nil {
+			return saved, fmt.Errorf("failed to save artwork: %w", err)
+		}
+		saved = append(saved, filepath.Join(dir, poster))
+	}
+
+	if x.sidecar {
+		data, err := lemon3libs.Sidecar{Cid: cid, Cast: cast, Metadata: meta}.ToJSON()
+		if err != nil {
+			return saved, err
+		}
+		sidecar := path + ".lemon3.json"
+		if err := os.WriteFile(sidecar, data, 0644); err != nil {
+			return saved, fmt.Errorf("failed to save sidecar: %w", err)
+		}
+		saved = append(saved, sidecar)
+	}
+
+	if x.nfo {
+		data, err := lemon3libs.Nfo(cid, cast, meta, poster)
+		if err != nil {
+			output.Status("nfo", output.Fields{"file": path, "skipped": err.Error()}, "[-] %v", err)
+			return saved, nil
+		}
+		nfo := filepath.Join(dir, base+".nfo")
+		if err := os.WriteFile(nfo, data, 0644); err != nil {
+			return saved, fmt.Errorf("failed to save .nfo: %w", err)
+		}
+		saved = append(saved, nfo)
+	}
+
+	for _, p := range saved {
+		output.Status("saved", output.Fields{"file": p}, "[+] Saved %s", p)
+	}
+
+	if tagMP3 {
+		tag := id3.Tag{
+			Title:    meta.Title,
+			Comment:  meta.Description,
+			Picture:  artwork,
+			UserText: map[string]string{"LEMON3_CID": cid},
+		}
+		if artwork != nil {
+			tag.PictureMime = http.DetectContentType(artwork)
+		}
+		if cast != nil {
+			tag.Artist = cast.Fname
+			tag.Date = time.Unix(int64(cast.Timestamp), 0)
+		}
+		if err := id3.WriteFile(path, tag); err != nil {
+			return saved, fmt.Errorf("failed to write ID3 tag: %w", err)
+		}
+		output.Status("tagged", output.Fields{"file": path}, "[+] Wrote ID3 tag to %s", path)
+	} else if x.id3 {
+		output.Status("id3", output.Fields{"file": path, "skipped": "not an MP3 file"}, "[-] %s is not an MP3 file, ID3 tag not written", path)
+	}
+
+	return saved, nil
+}
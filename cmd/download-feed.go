@@ -9,42 +9,48 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/errs"
 	"github.com/vrypan/lemon3/fcclient"
 	"github.com/vrypan/lemon3/ipfsclient"
 	"github.com/vrypan/lemon3/lemon3libs"
+	"github.com/vrypan/lemon3/output"
 )
 
 var download2Cmd = &cobra.Command{
 	Use:   "downloadfeed <user>",
 	Short: "Download lemon3 files shared by a user",
-	Run:   downloadFeed,
+	Long: `Download the lemon3 files shared by a user since the last run.
+
+Only the latest revision of files edited with "lemon3 edit" is downloaded.
+If a new revision encloses a file that was already downloaded, the file is
+renamed if needed, and only its metadata files are updated.`,
+	RunE: downloadFeed,
 }
 
-func downloadFeed(cmd *cobra.Command, args []string) {
+func downloadFeed(cmd *cobra.Command, args []string) error {
 	configFile := config.Load()
 	if configFile == "" {
-		fmt.Println("Please run \"lemon3 setup\" first.")
-		return
+		return errNoSetup
 	}
 	if len(args) == 0 {
-		fmt.Println("Usage: lemon3 download @user")
-		return
+		return errs.New(errs.UserInput, "usage: lemon3 downloadfeed @user")
 	}
 	username := args[0]
 
-	hubConf := fcclient.HubConfig{
-		Host: config.GetString("farcaster.node.address"),
-		Ssl:  config.GetString("farcaster.node.ssl") == "true",
-		Key:  config.GetString("farcaster.node.apikey"),
+	extras := extrasFromFlags(cmd)
+
+	hubConf := hubConfig()
+	if err := fcclient.Init(hubConf); err != nil {
+		return err
+	}
+	if err := ipfsclient.Init(config.GetString("ipfs.hub")); err != nil {
+		return err
 	}
-	fcclient.Init(hubConf)
-	ipfsclient.Init(config.GetString("ipfs.hub"))
 
 	downloadPath := filepath.Join(config.GetString("download.dir"), username[1:])
 	if _, err := os.Stat(downloadPath); os.IsNotExist(err) {
 		if err := os.MkdirAll(downloadPath, 0755); err != nil {
-			fmt.Printf("[!] Failed to create download directory: %v\n", err)
-			return
+			return fmt.Errorf("failed to create download directory: %w", err)
 		}
 	}
 
@@ -55,73 +61,131 @@ func downloadFeed(cmd *cobra.Command, args []string) {
 	statusDataBytes, err := os.ReadFile(statusFile)
 	if err == nil {
 		if err := json.Unmarshal(statusDataBytes, &status); err != nil {
-			fmt.Printf("[!] Failed to parse status file as JSON: %v\n", err)
-			return
+			return fmt.Errorf("failed to parse status file as JSON: %w", err)
 		}
 		if hash, ok := status["last_hash"].(string); ok {
 			lastCastHash = hash
 		} else {
-			fmt.Println("[!] Failed to get last_hash from status file.")
-			return
+			return fmt.Errorf("failed to get last_hash from status file")
 		}
 	}
 
 	casts, err := fcclient.GetCastsByFname(username[1:], 100, true)
 	if err != nil {
-		fmt.Printf("[!] Failed to get casts: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to get casts: %w", err)
 	}
 	if len(casts) == 0 {
-		fmt.Println("[!] No casts found for user.")
-		return
+		return errs.New(errs.NotFound, "no casts found for user")
 	}
 
 	status["last_hash"] = fmt.Sprintf("0x%x", casts[0].Hash)
 	status_casts := []*lemon3libs.L3Cast{}
+	downloaded := []string{}
+	revisions := lemon3libs.NewRevisions()
+	files := downloadedFiles(status)
+	seen := map[string]bool{} // enclosures of the casts of this run
 	for _, cast := range casts {
 		l3cast, err := lemon3libs.FromPbMessage(cast)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			return err
 		}
 		if l3cast == nil {
 			continue
 		}
 		if l3cast.Hash == lastCastHash {
-			return
+			break
+		}
+		if !revisions.Latest(l3cast) {
+			continue
 		}
 		l3cast.Fname = username[1:]
 		status_casts = append(status_casts, l3cast)
 
 		enclosed := l3cast.Lemon3Data.Enclosed["/"]
-		filename := l3cast.Lemon3Data.Filename
+		filename := lemon3libs.SafeFilename(l3cast.Lemon3Data.Filename)
+
+		if seen[enclosed] {
+			continue // a newer cast shares the same file
+		}
+		seen[enclosed] = true
+		if old, ok := files[enclosed]; ok && fileExists(filepath.Join(downloadPath, old)) {
+			// A new revision of a file we already have.
+			if old != filename {
+				if err := os.Rename(filepath.Join(downloadPath, old), filepath.Join(downloadPath, filename)); err != nil {
+					return fmt.Errorf("failed to rename %s: %w", old, err)
+				}
+			}
+			output.Status("unchanged", output.Fields{"file": filename, "cid": enclosed, "cast": l3cast.Hash},
+				"[=] %s is unchanged, updating its metadata", filename)
+		} else {
+			output.Status("download", output.Fields{"file": filename, "cid": enclosed, "cast": l3cast.Hash},
+				"[↓] Downloading %s from %s...", filename, enclosed)
+			err = ipfsclient.CatCIDToFile(enclosed, filepath.Join(downloadPath, filename), l3cast.Lemon3Data.Size)
+			if err != nil {
+				return fmt.Errorf("failed to download file: %w", err)
+			}
+		}
+		if _, err := extras.save(filepath.Join(downloadPath, filename), l3cast.Lemon3Cid, l3cast, l3cast.Lemon3Data, false); err != nil {
+			return err
+		}
+		downloaded = append(downloaded, filepath.Join(downloadPath, filename))
+	}
 
-		fmt.Printf("[↓] Downloading %s from %s...\n", filename, enclosed)
-		err = ipfsclient.CatCIDToFile(enclosed, filepath.Join(downloadPath, filename), l3cast.Lemon3Data.Size)
+	if len(status_casts) > 0 {
+		// Keep the casts downloaded in previous runs, after the new ones.
+		all := []any{}
+		for _, c := range status_casts {
+			all = append(all, c)
+		}
+		if previous, ok := status["casts"].([]any); ok {
+			all = append(all, previous...)
+		}
+		status["casts"] = all
+		statusBytes, err := json.MarshalIndent(status, "", "  ")
 		if err != nil {
-			fmt.Printf("[!] Failed to download file: %v\n", err)
-			return
+			return fmt.Errorf("failed to serialize status as JSON: %w", err)
+		}
+		if err := os.WriteFile(statusFile, statusBytes, 0644); err != nil {
+			return fmt.Errorf("failed to write status file: %w", err)
 		}
+	}
 
-		// fmt.Printf("[✓] Saved %s as %s               \n\n", cid, filename)
-		fmt.Println()
+	output.Result(
+		output.Fields{"user": username, "dir": downloadPath, "files": downloaded},
+		"[✓] %d new file(s) in %s", len(downloaded), downloadPath,
+	)
+	return nil
+}
 
+// downloadedFiles returns the files downloaded in previous runs, by enclosure CID.
+func downloadedFiles(status map[string]any) map[string]string {
+	files := map[string]string{}
+	previous, _ := status["casts"].([]any)
+	for _, c := range previous { // newest first
+		data, err := json.Marshal(c)
+		if err != nil {
+			continue
+		}
+		var l3cast lemon3libs.L3Cast
+		if err := json.Unmarshal(data, &l3cast); err != nil || l3cast.Lemon3Data == nil {
+			continue
+		}
+		enclosed := l3cast.Lemon3Data.Enclosed["/"]
+		if _, ok := files[enclosed]; !ok && enclosed != "" {
+			files[enclosed] = lemon3libs.SafeFilename(l3cast.Lemon3Data.Filename)
+		}
 	}
+	return files
+}
 
-	status["casts"] = status_casts
-	statusBytes, err := json.MarshalIndent(status, "", "  ")
-	if err != nil {
-		fmt.Printf("[!] Failed to serialize status as JSON: %v\n", err)
-		return
-	}
-	if err := os.WriteFile(statusFile, statusBytes, 0644); err != nil {
-		fmt.Printf("[!] Failed to write status file: %v\n", err)
-		return
-	}
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
 }
 
 func init() {
 	rootCmd.AddCommand(download2Cmd)
+	addExtrasFlags(download2Cmd)
 }
 
 func tsToDate(ts uint32) string {
@@ -2,13 +2,14 @@ package cmd
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/errs"
 	"github.com/vrypan/lemon3/fcclient"
 	"github.com/vrypan/lemon3/ipfsclient"
 	"github.com/vrypan/lemon3/lemon3libs"
+	"github.com/vrypan/lemon3/output"
 )
 
 var downloadCmd = &cobra.Command{
@@ -16,81 +17,76 @@ var downloadCmd = &cobra.Command{
 	Short: "Download file from a lemon3+ipfs:// cast",
 	Long: `Download the lemon3-enclosed file contained in a cast.
 
-The cast must be in the format @user/<hash>, for example
-lemon3 download @vrypan.eth/0xcd3141a47b98685c292b55c44f932e221753e51b
+The cast can be given as:
+  @user/<hash>           lemon3 download @vrypan.eth/0xcd3141a47b98685c292b55c44f932e221753e51b
+  fid:<fid>/<hash>       lemon3 download fid:280/0xcd3141a4
+  a Farcaster URL        lemon3 download https://farcaster.xyz/vrypan.eth/0xcd3141a4
+  a lemon3 URL or CID    lemon3 download lemon3+ipfs://bafyrei...
 
-Be carefule, you must provide the full hash, not the shortened version
-used in Farcaster URLs.`,
-	Run: download,
+Short hashes, like the ones in Farcaster URLs, are resolved by searching
+the user's casts.`,
+	RunE: download,
 }
 
-func download(cmd *cobra.Command, args []string) {
+func download(cmd *cobra.Command, args []string) error {
 	configFile := config.Load()
 	if configFile == "" {
-		fmt.Println("Please run \"lemon3 setup\" first.")
-		return
+		return errNoSetup
 	}
 
 	if len(args) == 0 {
-		fmt.Println("Usage: lemon3 download @user/<hash>")
-		return
+		return errs.New(errs.UserInput, "usage: lemon3 download <cast>")
 	}
-
-	castID := args[0]
-	parts := strings.Split(castID, "/")
-	if len(parts) != 2 || !strings.HasPrefix(parts[0], "@") {
-		fmt.Println("Invalid cast format. Use @user/<hash>")
-		return
-	}
-	username := strings.TrimPrefix(parts[0], "@")
-	hash := parts[1]
-
-	hubConf := fcclient.HubConfig{
-		Host: config.GetString("farcaster.node.address"),
-		Ssl:  config.GetString("farcaster.node.ssl") == "true",
-		Key:  config.GetString("farcaster.node.apikey"),
+	ref, err := lemon3libs.ParseCastRef(args[0])
+	if err != nil {
+		return err
 	}
-	fcclient.Init(hubConf)
 
-	embeds, err := fcclient.CastGetEmbedUrls(username, hash)
+	hub, err := fcclient.NewFarcasterHub(hubConfig())
 	if err != nil {
-		fmt.Printf("[!] Failed to fetch cast: %v\n", err)
-		return
+		return err
 	}
-
-	var cid string
-	for _, e := range embeds {
-		if strings.HasPrefix(e, "lemon3+ipfs://") {
-			cid = strings.TrimPrefix(e, "lemon3+ipfs://")
-			break
-		}
+	defer hub.Close()
+	cast, cid, err := resolveCastRef(hub, ref)
+	if err != nil {
+		return err
 	}
 
-	if cid == "" {
-		fmt.Println("[!] Failed to extract CID from embeddeds.")
-		return
+	if err := ipfsclient.Init(config.GetString("ipfs.hub")); err != nil {
+		return err
 	}
 
-	ipfsclient.Init(config.GetString("ipfs.hub"))
-
 	meta, err := lemon3libs.FromCid(cid)
 	if err != nil {
-		fmt.Printf("[!] %v\n", err)
-		return
+		return err
 	}
 	enclosed := meta.Enclosed["/"]
-	filename := meta.Filename
+	filename := lemon3libs.SafeFilename(meta.Filename)
 
-	fmt.Printf("[↓] Downloading %s from %s...\n", filename, enclosed)
+	output.Status("download", output.Fields{"file": filename, "cid": enclosed}, "[↓] Downloading %s from %s...", filename, enclosed)
 	err = ipfsclient.CatCIDToFile(enclosed, filename, meta.Size)
 	if err != nil {
-		fmt.Printf("[!] Failed to download file: %v\n", err)
-		return
+		return fmt.Errorf("failed to download file: %w", err)
+	}
+
+	var l3cast *lemon3libs.L3Cast
+	if cast != nil {
+		l3cast = lemon3libs.NewL3Cast(cast, meta)
+		l3cast.Fname = ref.Fname
+	}
+	extras, err := extrasFromFlags(cmd).save(filename, cid, l3cast, meta, true)
+	if err != nil {
+		return err
 	}
 
-	fmt.Printf("\r[✓] Saved as %s\n", filename)
+	output.Result(
+		output.Fields{"file": filename, "cid": enclosed, "metadata": cid, "size": meta.Size, "extras": extras},
+		"[✓] Saved as %s", filename,
+	)
+	return nil
 }
 
 func init() {
 	rootCmd.AddCommand(downloadCmd)
+	addExtrasFlags(downloadCmd)
 }
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/errs"
+	"github.com/vrypan/lemon3/fcclient"
+	"github.com/vrypan/lemon3/ipfsclient"
+	"github.com/vrypan/lemon3/lemon3libs"
+	"github.com/vrypan/lemon3/output"
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit <cast>",
+	Short: "Change the title or description of a file you shared",
+	Long: `Change the title or description of a file you shared, by publishing
+a new revision of its metadata and a new cast for it.
+
+The new metadata links to the old one as "previous", and the enclosed file
+and artwork are not uploaded again. "lemon3 ls" and "lemon3 downloadfeed"
+only show the latest revision. The new cast keeps the text and channel of
+the old one, unless --text or --channel are set. Use --remove-old to
+remove the old cast.`,
+	Example: `lemon3 edit @alice/0xcd3141a4 --title "Episode 1: The Beginning" --remove-old`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    edit,
+}
+
+func edit(cmd *cobra.Command, args []string) error {
+	configFile := config.Load()
+	if configFile == "" {
+		return errNoSetup
+	}
+	ref, err := lemon3libs.ParseCastRef(args[0])
+	if err != nil {
+		return err
+	}
+	if !cmd.Flags().Changed("title") && !cmd.Flags().Changed("description") {
+		return errs.New(errs.UserInput, "nothing to change, use --title or --description")
+	}
+	title, _ := cmd.Flags().GetString("title")
+	description, _ := cmd.Flags().GetString("description")
+	if description, err = readDescription(description); err != nil {
+		return err
+	}
+
+	pub, err := publisherFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+	if err := ipfsclient.Init(config.GetString("ipfs.hub")); err != nil {
+		return err
+	}
+	cast, oldCid, err := resolveOwnCast(pub, ref)
+	if err != nil {
+		return err
+	}
+
+	meta, err := lemon3libs.FromCid(oldCid)
+	if err != nil {
+		return err
+	}
+	// Start from the stored object, to keep the fields this version of lemon3 does not know.
+	data, err := ipfsclient.DagGet(oldCid)
+	if err != nil {
+		return fmt.Errorf("failed to fetch metadata: %w", err)
+	}
+	if cmd.Flags().Changed("title") {
+		data["title"] = title
+	}
+	if cmd.Flags().Changed("description") {
+		data["description"] = description
+	}
+	data["previous"] = map[string]string{"/": oldCid}
+
+	newCid, err := ipfsclient.DagPut(data)
+	if err != nil {
+		return fmt.Errorf("failed to upload metadata: %w", err)
+	}
+	output.Status("metadata", output.Fields{"cid": newCid, "previous": oldCid}, "[^] Metadata cid=%s (replaces %s)", newCid, oldCid)
+	if err := pinFile(newCid, meta); err != nil {
+		return err
+	}
+	if err := ipfsclient.ProvideCIDRecursive(newCid); err != nil {
+		return fmt.Errorf("failed to announce metadata: %w", err)
+	}
+	output.Status("pinned", output.Fields{"cid": newCid, "role": "metadata"}, "[+] %s pinned.", newCid)
+
+	body := cast.Data.GetCastAddBody()
+	text := body.GetText()
+	if cmd.Flags().Changed("text") {
+		text, _ = cmd.Flags().GetString("text")
+	}
+	parentUrl := body.GetParentUrl()
+	if cmd.Flags().Changed("channel") {
+		channel, _ := cmd.Flags().GetString("channel")
+		if parentUrl, err = pub.channelUrl(channel); err != nil {
+			return err
+		}
+	}
+	up := uploadResult{Filename: meta.Filename, Metadata: newCid, Enclosed: meta.Enclosed["/"]}
+	castHash, err := pub.cast(text, up, parentUrl)
+	if err != nil {
+		return err
+	}
+
+	result := output.Fields{
+		"cast":     fmt.Sprintf("@%s/0x%s", pub.username, castHash),
+		"hash":     "0x" + castHash,
+		"url":      fmt.Sprintf("https://farcaster.xyz/%s/0x%s", pub.username, castHash),
+		"metadata": newCid,
+		"previous": oldCid,
+		"enclosed": up.Enclosed,
+	}
+	if removeOld, _ := cmd.Flags().GetBool("remove-old"); removeOld {
+		if _, err := fcclient.RemoveCast(pub.hubConf, pub.username, pub.key, cast.Hash); err != nil {
+			return fmt.Errorf("the new cast was posted, but the old one could not be removed: %w", err)
+		}
+		output.Status("removed", output.Fields{"hash": fmt.Sprintf("0x%x", cast.Hash)}, "[-] Removed @%s/0x%x", pub.username, cast.Hash)
+		result["removed"] = fmt.Sprintf("0x%x", cast.Hash)
+	}
+	output.Result(result, "\nView cast: https://farcaster.xyz/%s/0x%s", pub.username, castHash)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(editCmd)
+	editCmd.Flags().String("title", "", "New title")
+	editCmd.Flags().String("description", "", "New description. @file will read the text from file, @- will read the text from stdin.")
+	editCmd.Flags().Bool("remove-old", false, "Remove the old cast after posting the new one")
+	addCastFlags(editCmd)
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/errs"
+	"github.com/vrypan/lemon3/fcclient"
+	"github.com/vrypan/lemon3/ipfsclient"
+	"github.com/vrypan/lemon3/lemon3libs"
+	"github.com/vrypan/lemon3/output"
+)
+
+var infoCmd = &cobra.Command{
+	Use:   "info <cast|cid>",
+	Short: "Show the details of a lemon3 cast or metadata CID",
+	Long: `Show the metadata of a lemon3 cast, the cast itself, and the
+availability of the enclosed file, without downloading it.
+
+The argument can be @user/<hash>, fid:<fid>/<hash>, a farcaster.xyz
+cast URL, or a lemon3 metadata CID. The hash can be shortened, like
+in Farcaster URLs. For example:
+lemon3 info @vrypan.eth/0xcd3141a47b98685c292b55c44f932e221753e51b`,
+	RunE: info,
+}
+
+func info(cmd *cobra.Command, args []string) error {
+	configFile := config.Load()
+	if configFile == "" {
+		return errNoSetup
+	}
+	if len(args) != 1 {
+		return errs.New(errs.UserInput, "usage: lemon3 info <cast|cid>")
+	}
+	ref, err := lemon3libs.ParseCastRef(args[0])
+	if err != nil {
+		return err
+	}
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+
+	hub, err := fcclient.NewFarcasterHub(hubConfig())
+	if err != nil {
+		return err
+	}
+	defer hub.Close()
+	cast, cid, err := resolveCastRef(hub, ref)
+	if err != nil {
+		return err
+	}
+	if err := ipfsclient.Init(config.GetString("ipfs.hub")); err != nil {
+		return err
+	}
+
+	fields := output.Fields{"metadata_cid": cid}
+	var text strings.Builder
+	w := tabwriter.NewWriter(&text, 0, 0, 2, ' ', 0)
+	row := func(name string, format string, a ...any) {
+		fmt.Fprintf(w, "%s\t"+format+"\n", append([]any{name}, a...)...)
+	}
+
+	if cast != nil {
+		date := time.Unix(int64(cast.Data.Timestamp)+lemon3libs.FARCASTER_EPOCH, 0)
+		hash := fmt.Sprintf("0x%x", cast.Hash)
+		fields["cast"] = output.Fields{
+			"hash":  hash,
+			"fname": ref.Fname,
+			"fid":   cast.Data.Fid,
+			"date":  date.UTC().Format(time.RFC3339),
+			"text":  cast.Data.GetCastAddBody().GetText(),
+		}
+		if ref.Fname != "" {
+			row("Cast", "@%s/%s", ref.Fname, hash)
+			row("Author", "@%s (FID %d)", ref.Fname, cast.Data.Fid)
+		} else {
+			row("Cast", "fid:%d/%s", cast.Data.Fid, hash)
+			row("Author", "FID %d", cast.Data.Fid)
+		}
+		row("Date", "%s", date.Format("2006-01-02 15:04:05"))
+		row("Text", "%s", strings.ReplaceAll(cast.Data.GetCastAddBody().GetText(), "\n", "\n\t"))
+	}
+	row("Metadata", "%s", cid)
+
+	meta, err := lemon3libs.FromCid(cid)
+	if errs.KindOf(err) == errs.Verification {
+		fields["valid"] = false
+		fields["problems"] = err.Error()
+		row("Valid", "no, %v", err)
+		w.Flush()
+		output.Result(fields, "%s", strings.TrimSuffix(text.String(), "\n"))
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	validErr := meta.Validate()
+	fields["metadata"] = meta
+	row("Title", "%s", meta.Title)
+	row("Description", "%s", strings.ReplaceAll(meta.Description, "\n", "\n\t"))
+	row("Type", "%s", meta.Type)
+	row("Filename", "%s", meta.Filename)
+	row("Size", "%s (%d bytes)", lemon3libs.HumanSize(meta.Size), meta.Size)
+	if meta.Duration > 0 {
+		row("Duration", "%s", time.Duration(meta.Duration*float64(time.Second)).Round(time.Second))
+	}
+
+	enclosed := meta.Enclosed["/"]
+	enclosure := cidInfo(enclosed, timeout)
+	if stat, err := ipfsclient.Stat(enclosed, timeout); err != nil {
+		enclosure["error"] = err.Error()
+	} else {
+		enclosure["size"] = stat.Size
+		if validErr == nil && stat.Size != meta.Size {
+			validErr = errs.New(errs.Verification, "size is %d, but the enclosed file is %d bytes", meta.Size, stat.Size)
+		}
+	}
+	fields["enclosure"] = enclosure
+	row("Enclosure", "%s", formatCidInfo(enclosed, enclosure))
+
+	if artwork := meta.Artwork["/"]; artwork != "" {
+		fields["artwork"] = imageInfo(artwork, meta.ArtworkWidth, meta.ArtworkHeight, timeout)
+		row("Artwork", "%s", formatCidInfo(artwork, fields["artwork"].(output.Fields)))
+	} else {
+		row("Artwork", "none")
+	}
+	if thumbnail := meta.Thumbnail["/"]; thumbnail != "" {
+		fields["thumbnail"] = imageInfo(thumbnail, meta.ThumbnailWidth, meta.ThumbnailHeight, timeout)
+		row("Thumbnail", "%s", formatCidInfo(thumbnail, fields["thumbnail"].(output.Fields)))
+	}
+
+	if previous := meta.Previous["/"]; previous != "" {
+		row("Previous", "%s", previous)
+	}
+
+	fields["valid"] = validErr == nil
+	if validErr != nil {
+		fields["problems"] = validErr.Error()
+		row("Valid", "no, %v", validErr)
+	} else {
+		row("Valid", "yes")
+	}
+	w.Flush()
+	output.Result(fields, "%s", strings.TrimSuffix(text.String(), "\n"))
+	return validErr
+}
+
+// cidInfo checks if cid is pinned on the local node, and how many providers the DHT knows.
+func cidInfo(cid string, timeout time.Duration) output.Fields {
+	fields := output.Fields{"cid": cid}
+	if pinned, err := ipfsclient.IsPinned(cid); err == nil {
+		fields["pinned"] = pinned
+	}
+	if providers, err := ipfsclient.FindProviders(cid, 20, timeout); err == nil {
+		fields["providers"] = providers
+	}
+	return fields
+}
+
+// imageInfo is cidInfo with the dimensions of an image, if they are known.
+func imageInfo(cid string, width, height int, timeout time.Duration) output.Fields {
+	fields := cidInfo(cid, timeout)
+	if width > 0 && height > 0 {
+		fields["width"] = width
+		fields["height"] = height
+	}
+	return fields
+}
+
+func formatCidInfo(cid string, fields output.Fields) string {
+	parts := []string{}
+	if width, ok := fields["width"].(int); ok {
+		parts = append(parts, fmt.Sprintf("%dx%d", width, fields["height"]))
+	}
+	if size, ok := fields["size"].(int64); ok {
+		parts = append(parts, lemon3libs.HumanSize(size))
+	}
+	if pinned, ok := fields["pinned"].(bool); ok {
+		if pinned {
+			parts = append(parts, "pinned locally")
+		} else {
+			parts = append(parts, "not pinned locally")
+		}
+	}
+	if providers, ok := fields["providers"].(int); ok {
+		if providers >= 20 {
+			parts = append(parts, "20+ providers")
+		} else {
+			parts = append(parts, fmt.Sprintf("%d provider(s)", providers))
+		}
+	}
+	if err, ok := fields["error"].(string); ok {
+		parts = append(parts, err)
+	}
+	if len(parts) == 0 {
+		return cid
+	}
+	return fmt.Sprintf("%s (%s)", cid, strings.Join(parts, ", "))
+}
+
+func init() {
+	rootCmd.AddCommand(infoCmd)
+	infoCmd.Flags().Duration("timeout", 10*time.Second, "How long to search the network for the enclosed file and its providers")
+}
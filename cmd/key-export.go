@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/appkey"
+	"github.com/vrypan/lemon3/config"
+)
+
+var keyexportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Print the app key in plaintext",
+	RunE:  key_export,
+}
+
+func key_export(cmd *cobra.Command, args []string) error {
+	config.Load()
+	source, err := appkey.FromConfig()
+	if err != nil {
+		return err
+	}
+	key, err := source.Load()
+	if err != nil {
+		return err
+	}
+	fmt.Println(appkey.Hex(key))
+	return nil
+}
+
+func init() {
+	keyCmd.AddCommand(keyexportCmd)
+}
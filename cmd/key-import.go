@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/vrypan/lemon3/appkey"
+	"github.com/vrypan/lemon3/config"
+	"golang.org/x/term"
+)
+
+var keyimportCmd = &cobra.Command{
+	Use:   "import [key]",
+	Short: "Encrypt the app key with a passphrase",
+	Long: `Encrypt the app key and store it in the configuration directory.
+
+Without arguments, the plaintext farcaster.account.appkey is imported
+and removed from the configuration file. If there is none, you will
+be asked to enter the key.`,
+	RunE: key_import,
+}
+
+func key_import(cmd *cobra.Command, args []string) error {
+	config.Load()
+	plaintext := config.GetString("farcaster.account.appkey")
+
+	var hexKey string
+	switch {
+	case len(args) > 0:
+		hexKey = args[0]
+	case plaintext != "":
+		hexKey = plaintext
+		fmt.Println("Importing farcaster.account.appkey.")
+	default:
+		fmt.Fprint(os.Stderr, "App key: ")
+		b, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return err
+		}
+		hexKey = string(b)
+	}
+	key, err := appkey.Parse(hexKey)
+	if err != nil {
+		return err
+	}
+
+	passphrase, err := appkey.ReadNewPassphrase()
+	if err != nil {
+		return err
+	}
+	path, err := saveEncryptedKey(key, passphrase)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("[+] Encrypted key saved to %s\n", path)
+
+	if plaintext != "" {
+		viper.Set("farcaster.account.appkey", "")
+		if err := viper.WriteConfig(); err != nil {
+			return fmt.Errorf("failed to remove farcaster.account.appkey from config: %w", err)
+		}
+		fmt.Println("[+] Removed farcaster.account.appkey from config.")
+	}
+	return nil
+}
+
+// saveEncryptedKey encrypts key with passphrase and writes it to the profile's encrypted key file.
+func saveEncryptedKey(key ed25519.PrivateKey, passphrase []byte) (string, error) {
+	data, err := appkey.Encrypt(key, passphrase)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt key: %w", err)
+	}
+	path, err := appkey.EncryptedPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
+
+func init() {
+	keyCmd.AddCommand(keyimportCmd)
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/appkey"
+	"github.com/vrypan/lemon3/config"
+)
+
+var keyshowpublicCmd = &cobra.Command{
+	Use:   "show-public",
+	Short: "Print the public key of the app key",
+	RunE:  key_show_public,
+}
+
+func key_show_public(cmd *cobra.Command, args []string) error {
+	config.Load()
+	source, err := appkey.FromConfig()
+	if err != nil {
+		return err
+	}
+	key, err := source.Load()
+	if err != nil {
+		return err
+	}
+	fmt.Println(appkey.PublicHex(key))
+	return nil
+}
+
+func init() {
+	keyCmd.AddCommand(keyshowpublicCmd)
+}
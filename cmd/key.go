@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var keyCmd = &cobra.Command{
+	Use:   "key",
+	Short: "Manage the Farcaster app key",
+	Long: `Manage the app key used to sign casts.
+
+The app key is loaded from, in order of preference:
+- the output of farcaster.account.appkey_cmd, for example "pass show lemon3"
+- the encrypted key file created by "lemon3 key import"
+- farcaster.account.appkey (plaintext, not recommended)
+
+Set LEMON3_PASSPHRASE to avoid the passphrase prompt.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(keyCmd)
+}
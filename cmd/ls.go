@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/errs"
+	"github.com/vrypan/lemon3/fcclient"
+	"github.com/vrypan/lemon3/ipfsclient"
+	"github.com/vrypan/lemon3/lemon3libs"
+	"github.com/vrypan/lemon3/output"
+)
+
+var lsCmd = &cobra.Command{
+	Use:   "ls @user",
+	Short: "List the lemon3 files shared by a user",
+	Long: `List the lemon3 casts of a user, newest first. Files edited with
+"lemon3 edit" are listed once, with their latest title and description.
+
+Examples:
+lemon3 ls @vrypan.eth --type "audio/*" --since 30d
+lemon3 ls @vrypan.eth --min-size 10MB --sort size
+lemon3 ls @vrypan.eth --limit 10 --offset 10`,
+	RunE: ls,
+}
+
+var lsSortKeys = []string{"date", "size", "title", "type"}
+
+func ls(cmd *cobra.Command, args []string) error {
+	configFile := config.Load()
+	if configFile == "" {
+		return errNoSetup
+	}
+	if len(args) != 1 {
+		return errs.New(errs.UserInput, "usage: lemon3 ls @user")
+	}
+	username := strings.TrimPrefix(args[0], "@")
+
+	typePattern, _ := cmd.Flags().GetString("type")
+	if typePattern != "" && !strings.Contains(typePattern, "/") {
+		typePattern += "/*"
+	}
+	if _, err := path.Match(typePattern, ""); err != nil {
+		return errs.New(errs.UserInput, "invalid --type pattern %q", typePattern)
+	}
+	var since time.Time
+	if s, _ := cmd.Flags().GetString("since"); s != "" {
+		var err error
+		if since, err = parseSince(s, time.Now()); err != nil {
+			return errs.Wrap(errs.UserInput, err)
+		}
+	}
+	var minSize int64
+	if s, _ := cmd.Flags().GetString("min-size"); s != "" {
+		var err error
+		if minSize, err = lemon3libs.ParseSize(s); err != nil {
+			return errs.Wrap(errs.UserInput, err)
+		}
+	}
+	sortKey, _ := cmd.Flags().GetString("sort")
+	if !slices.Contains(lsSortKeys, sortKey) {
+		return errs.New(errs.UserInput, "invalid --sort %q, use one of %s", sortKey, strings.Join(lsSortKeys, ", "))
+	}
+	reverse, _ := cmd.Flags().GetBool("reverse")
+	limit, _ := cmd.Flags().GetInt("limit")
+	offset, _ := cmd.Flags().GetInt("offset")
+	if limit < 0 || offset < 0 {
+		return errs.New(errs.UserInput, "--limit and --offset can not be negative")
+	}
+
+	if err := ipfsclient.Init(config.GetString("ipfs.hub")); err != nil {
+		return err
+	}
+	hub, err := fcclient.NewFarcasterHub(hubConfig())
+	if err != nil {
+		return err
+	}
+	defer hub.Close()
+	fid, err := hub.GetFidByUsername(username)
+	if err != nil {
+		return fmt.Errorf("unable to get FID for @%s: %w", username, err)
+	}
+
+	// Casts come newest first. When listing by date, stop as soon as the
+	// requested page is complete (plus one, to know if there are more).
+	enough := 0
+	if sortKey == "date" && !reverse && limit > 0 {
+		enough = offset + limit + 1
+	}
+	casts := []*lemon3libs.L3Cast{}
+	revisions := lemon3libs.NewRevisions()
+	scanned := 0
+	var pageToken []byte
+scan:
+	for {
+		page, err := hub.GetCastsByFidPage(fid, 100, true, pageToken)
+		if err != nil {
+			if scanned > 0 {
+				output.EndProgress()
+			}
+			return fmt.Errorf("failed to get casts of @%s: %w", username, err)
+		}
+		for _, msg := range page.Messages {
+			scanned++
+			output.Progress(output.Fields{"scanned": scanned, "found": len(casts)},
+				"[^] Scanned %d casts, found %d", scanned, len(casts))
+			if !since.IsZero() && int64(msg.Data.Timestamp)+lemon3libs.FARCASTER_EPOCH < since.Unix() {
+				break scan
+			}
+			l3cast, err := lemon3libs.FromPbMessage(msg)
+			if err != nil {
+				output.EndProgress()
+				output.Status("skip", output.Fields{"hash": fmt.Sprintf("0x%x", msg.Hash), "error": err.Error()},
+					"[!] Skipping 0x%x: %v", msg.Hash, err)
+				continue
+			}
+			if l3cast == nil || !revisions.Latest(l3cast) || !lsMatch(l3cast.Lemon3Data, typePattern, minSize) {
+				continue
+			}
+			l3cast.Fname = username
+			casts = append(casts, l3cast)
+			if enough > 0 && len(casts) >= enough {
+				break scan
+			}
+		}
+		if len(page.NextPageToken) == 0 || len(page.Messages) == 0 {
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+	if scanned > 0 {
+		output.EndProgress()
+	}
+
+	sortCasts(casts, sortKey, reverse)
+
+	total := len(casts)
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+	casts = casts[offset:end]
+
+	items := make([]output.Fields, len(casts))
+	var table strings.Builder
+	w := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tTITLE\tTYPE\tSIZE\tHASH\tCID")
+	for i, c := range casts {
+		date := time.Unix(int64(c.Timestamp), 0).Format("2006-01-02 15:04")
+		items[i] = output.Fields{
+			"date":      time.Unix(int64(c.Timestamp), 0).UTC().Format(time.RFC3339),
+			"title":     c.Lemon3Data.Title,
+			"type":      c.Lemon3Data.Type,
+			"size":      c.Lemon3Data.Size,
+			"hash":      c.Hash,
+			"cid":       c.Lemon3Data.Enclosed["/"],
+			"lemon3cid": c.Lemon3Cid,
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", date, c.Lemon3Data.Title, c.Lemon3Data.Type,
+			lemon3libs.HumanSize(c.Lemon3Data.Size), c.Hash, c.Lemon3Data.Enclosed["/"])
+	}
+	w.Flush()
+
+	fields := output.Fields{"user": username, "fid": fid, "casts": items}
+	text := strings.TrimSuffix(table.String(), "\n")
+	if end < total {
+		fields["next_offset"] = end
+		text += fmt.Sprintf("\n\nMore results available, use --offset %d.", end)
+	}
+	output.Result(fields, "%s", text)
+	return nil
+}
+
+// lsMatch checks the metadata against the --type and --min-size filters.
+func lsMatch(meta *lemon3libs.Lemon3Metadata, typePattern string, minSize int64) bool {
+	if typePattern != "" {
+		if ok, _ := path.Match(typePattern, meta.Type); !ok {
+			return false
+		}
+	}
+	return meta.Size >= minSize
+}
+
+// sortCasts sorts by key, newest/largest first for date and size, A-Z for title and type.
+func sortCasts(casts []*lemon3libs.L3Cast, key string, reverse bool) {
+	less := func(a, b *lemon3libs.L3Cast) bool {
+		switch key {
+		case "size":
+			return a.Lemon3Data.Size > b.Lemon3Data.Size
+		case "title":
+			return strings.ToLower(a.Lemon3Data.Title) < strings.ToLower(b.Lemon3Data.Title)
+		case "type":
+			return a.Lemon3Data.Type < b.Lemon3Data.Type
+		default:
+			return a.Timestamp > b.Timestamp
+		}
+	}
+	sort.SliceStable(casts, func(i, j int) bool {
+		if reverse {
+			return less(casts[j], casts[i])
+		}
+		return less(casts[i], casts[j])
+	})
+}
+
+/*
+parseSince parses a date (2006-01-02), an RFC3339 time, or a duration
+before now, like "72h" or "30d".
+*/
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q, use a date (2006-01-02) or a duration (30d, 72h)", s)
+}
+
+func init() {
+	rootCmd.AddCommand(lsCmd)
+	lsCmd.Flags().String("type", "", "Only list files of this MIME type, for example \"audio/*\" or \"video/mp4\"")
+	lsCmd.Flags().String("since", "", "Only list casts since a date (2006-01-02) or a duration (30d, 72h)")
+	lsCmd.Flags().String("min-size", "", "Only list files of at least this size, for example 10MB")
+	lsCmd.Flags().String("sort", "date", "Sort by date, size, title, or type")
+	lsCmd.Flags().Bool("reverse", false, "Reverse the sort order")
+	lsCmd.Flags().Int("limit", 20, "Number of results to show, 0 for all")
+	lsCmd.Flags().Int("offset", 0, "Number of results to skip")
+}
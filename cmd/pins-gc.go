@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/errs"
+	"github.com/vrypan/lemon3/fcclient"
+	"github.com/vrypan/lemon3/ipfsclient"
+	"github.com/vrypan/lemon3/lemon3libs"
+	"github.com/vrypan/lemon3/output"
+	"github.com/vrypan/lemon3/pins"
+	"github.com/vrypan/lemon3/queue"
+)
+
+var pinsgcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Unpin the files of casts that were never published, or were removed",
+	Long: `Unpin the files of the active profile that have no cast: files that
+were uploaded but never cast (and are not in the queue), and files whose
+cast was removed. CIDs that another file still uses stay pinned.
+
+Unpinned content is deleted by the next "ipfs repo gc".`,
+	RunE: pins_gc,
+}
+
+func pins_gc(cmd *cobra.Command, args []string) error {
+	configFile := config.Load()
+	if configFile == "" {
+		return errNoSetup
+	}
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	olderThan, _ := cmd.Flags().GetDuration("older-than")
+
+	path, err := pinsPath()
+	if err != nil {
+		return err
+	}
+	r, err := pins.Load(path)
+	if err != nil {
+		return err
+	}
+	qPath, err := queuePath()
+	if err != nil {
+		return err
+	}
+	q, err := queue.Load(qPath)
+	if err != nil {
+		return err
+	}
+	queued := map[string]bool{}
+	for _, item := range q.Items {
+		if item.Status == queue.Pending || item.Status == queue.Sending {
+			queued[item.Metadata] = true
+		}
+	}
+
+	hub, err := fcclient.NewFarcasterHub(hubConfig())
+	if err != nil {
+		return err
+	}
+	defer hub.Close()
+	drop := map[string]bool{}
+	checked := map[string]bool{}
+	for _, p := range r.Pins {
+		if p.Profile != config.ActiveProfile() || checked[p.Metadata] {
+			continue
+		}
+		checked[p.Metadata] = true
+		casts := r.Casts(p.Metadata)
+		if len(casts) == 0 {
+			if !queued[p.Metadata] && time.Since(p.Pinned) > olderThan {
+				drop[p.Metadata] = true
+				output.Status("unpublished", output.Fields{"metadata": p.Metadata}, "[-] %s was never published", p.Metadata)
+			}
+			continue
+		}
+		// The file stays pinned while any of its casts is still there.
+		removed, err := r.Removed(p.Metadata, func(c pins.Cast) (bool, error) {
+			gone, err := castRemoved(hub, c)
+			if err != nil {
+				return false, fmt.Errorf("failed to check %s: %w", c.Ref, err)
+			}
+			return gone, nil
+		})
+		if err != nil {
+			return err
+		}
+		if removed {
+			drop[p.Metadata] = true
+			refs := []string{}
+			for _, c := range casts {
+				refs = append(refs, c.Ref)
+			}
+			output.Status("removed", output.Fields{"metadata": p.Metadata, "casts": refs},
+				"[-] %s: %s removed", p.Metadata, strings.Join(refs, ", "))
+		}
+	}
+
+	unused := r.Unused(drop)
+	if dryRun {
+		for _, cid := range unused {
+			output.Status("unpin", output.Fields{"cid": cid}, "[-] %s would be unpinned", cid)
+		}
+		output.Result(output.Fields{"unpin": unused, "dry_run": true}, "[✓] %d CIDs would be unpinned", len(unused))
+		return nil
+	}
+
+	if err := ipfsclient.Init(config.GetString("ipfs.hub")); err != nil {
+		return err
+	}
+	unpinned := []string{}
+	var unpinErr error
+	for _, cid := range unused {
+		if unpinErr = ipfsclient.UnpinCID(cid); unpinErr != nil {
+			break
+		}
+		unpinned = append(unpinned, cid)
+		output.Status("unpinned", output.Fields{"cid": cid}, "[-] %s unpinned.", cid)
+	}
+	updatePins(func(r *pins.Registry) {
+		for _, cid := range unpinned {
+			r.Remove(cid)
+		}
+		if unpinErr == nil {
+			for metadata := range drop {
+				r.RemoveMetadata(metadata)
+			}
+		}
+	})
+	if unpinErr != nil {
+		return fmt.Errorf("failed to unpin: %w", unpinErr)
+	}
+	output.Result(output.Fields{"unpinned": unpinned}, "[✓] %d CIDs unpinned", len(unpinned))
+	return nil
+}
+
+/*
+castRemoved checks if cast c is gone from the hub. The cast is looked up
+by FID, since the fname in c.Ref may now belong to another account, or
+to none.
+*/
+func castRemoved(hub *fcclient.FarcasterHub, c pins.Cast) (bool, error) {
+	ref, err := lemon3libs.ParseCastRef(c.Ref)
+	if err != nil {
+		return false, err
+	}
+	if ref.IsShort() {
+		return false, errs.New(errs.UserInput, "%s is not a full cast hash", c.Ref)
+	}
+	fid := c.Fid
+	if fid == 0 { // the FID is unknown
+		if fid, err = hub.GetFidByUsername(ref.Fname); err != nil {
+			return false, err
+		}
+	}
+	hash, _ := hex.DecodeString(strings.TrimPrefix(ref.Hash, "0x")) // validated by ParseCastRef
+	_, err = hub.GetCast(fid, hash)
+	if errs.KindOf(err) == errs.NotFound {
+		return true, nil
+	}
+	return false, err
+}
+
+func init() {
+	pinsCmd.AddCommand(pinsgcCmd)
+	pinsgcCmd.Flags().Bool("dry-run", false, "List what would be unpinned, without unpinning it")
+	pinsgcCmd.Flags().Duration("older-than", 24*time.Hour, "Only unpin files that were never published if they were pinned at least this long ago")
+}
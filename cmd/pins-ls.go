@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/lemon3libs"
+	"github.com/vrypan/lemon3/output"
+	"github.com/vrypan/lemon3/pins"
+)
+
+var pinslsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List the CIDs lemon3 pinned",
+	RunE:  pins_ls,
+}
+
+func pins_ls(cmd *cobra.Command, args []string) error {
+	config.Load()
+	all, _ := cmd.Flags().GetBool("all")
+	path, err := pinsPath()
+	if err != nil {
+		return err
+	}
+	r, err := pins.Load(path)
+	if err != nil {
+		return err
+	}
+
+	listed := []*pins.Pin{}
+	var table strings.Builder
+	w := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tROLE\tSIZE\tCID\tCAST")
+	for _, p := range r.Pins {
+		if p.Profile != config.ActiveProfile() && !all {
+			continue
+		}
+		listed = append(listed, p)
+		size := "-"
+		if p.Size > 0 {
+			size = lemon3libs.HumanSize(p.Size)
+		}
+		cast := "unpublished"
+		if len(p.Casts) > 0 {
+			cast = p.Casts[len(p.Casts)-1].Ref
+			if len(p.Casts) > 1 {
+				cast += fmt.Sprintf(" (+%d)", len(p.Casts)-1)
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", p.Pinned.Local().Format("2006-01-02 15:04"), p.Role, size, p.Cid, cast)
+	}
+	w.Flush()
+
+	text := strings.TrimSuffix(table.String(), "\n")
+	if len(listed) == 0 {
+		text = "No pins."
+	}
+	output.Result(output.Fields{"pins": listed}, "%s", text)
+	return nil
+}
+
+func init() {
+	pinsCmd.AddCommand(pinslsCmd)
+	pinslsCmd.Flags().Bool("all", false, "List the pins of all profiles")
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/ipfsclient"
+	"github.com/vrypan/lemon3/output"
+)
+
+var pinsrepairCmd = &cobra.Command{
+	Use:   "repair",
+	Short: "Pin again the CIDs of the pin registry that are not pinned",
+	Long: `Pin again the CIDs of the pin registry that are not pinned on the
+local node. Content that is no longer on the node is fetched from the
+network, which can take a while.`,
+	RunE: pins_repair,
+}
+
+func pins_repair(cmd *cobra.Command, args []string) error {
+	missing, total, err := missingPins()
+	if err != nil {
+		return err
+	}
+	for _, cid := range missing {
+		output.Status("pin", output.Fields{"cid": cid}, "[^] Pinning %s", cid)
+		if err := ipfsclient.PinCID(cid); err != nil {
+			return fmt.Errorf("failed to pin %s: %w", cid, err)
+		}
+		output.Status("pinned", output.Fields{"cid": cid}, "[+] %s pinned.", cid)
+	}
+	output.Result(output.Fields{"checked": total, "repaired": missing},
+		"[✓] %d of %d CIDs pinned again", len(missing), total)
+	return nil
+}
+
+func init() {
+	pinsCmd.AddCommand(pinsrepairCmd)
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/errs"
+	"github.com/vrypan/lemon3/ipfsclient"
+	"github.com/vrypan/lemon3/output"
+	"github.com/vrypan/lemon3/pins"
+)
+
+var pinsverifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check that the CIDs in the pin registry are pinned on the local node",
+	RunE:  pins_verify,
+}
+
+func pins_verify(cmd *cobra.Command, args []string) error {
+	missing, total, err := missingPins()
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		output.Result(output.Fields{"checked": total, "missing": missing},
+			"[!] %d of %d CIDs are not pinned, run \"lemon3 pins repair\" to pin them again", len(missing), total)
+		return errs.New(errs.Verification, "%d CIDs are not pinned", len(missing))
+	}
+	output.Result(output.Fields{"checked": total, "missing": missing}, "[✓] All %d CIDs are pinned", total)
+	return nil
+}
+
+// missingPins returns the CIDs of the pin registry that are not pinned on the local node, and the number of CIDs checked.
+func missingPins() ([]string, int, error) {
+	config.Load()
+	path, err := pinsPath()
+	if err != nil {
+		return nil, 0, err
+	}
+	r, err := pins.Load(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := ipfsclient.Init(config.GetString("ipfs.hub")); err != nil {
+		return nil, 0, err
+	}
+	cids := r.Cids()
+	missing := []string{}
+	for i, cid := range cids {
+		output.Progress(output.Fields{"checked": i + 1, "total": len(cids)}, "[^] Checking %d/%d", i+1, len(cids))
+		pinned, err := ipfsclient.IsPinned(cid)
+		if err != nil {
+			output.EndProgress()
+			return nil, 0, err
+		}
+		if !pinned {
+			missing = append(missing, cid)
+		}
+	}
+	if len(cids) > 0 {
+		output.EndProgress()
+	}
+	for _, cid := range missing {
+		output.Status("missing", output.Fields{"cid": cid}, "[!] %s is not pinned", cid)
+	}
+	return missing, len(cids), nil
+}
+
+func init() {
+	pinsCmd.AddCommand(pinsverifyCmd)
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/errs"
+	"github.com/vrypan/lemon3/ipfsclient"
+	"github.com/vrypan/lemon3/lemon3libs"
+	"github.com/vrypan/lemon3/output"
+	"github.com/vrypan/lemon3/pins"
+)
+
+var pinsCmd = &cobra.Command{
+	Use:   "pins",
+	Short: "Manage the files lemon3 pinned on the local IPFS node",
+	Long: `Manage the files lemon3 pinned on the local IPFS node.
+
+lemon3 keeps a registry of the CIDs it pins when you upload, cast, recast
+or edit a file: the enclosed file, its artwork and thumbnail, and its
+metadata, with the casts they were published with.
+
+Downloaded files are not pinned by lemon3, and are not in the registry.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// pinsPath returns the path of the pin registry, shared by all profiles.
+func pinsPath() (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", errs.Wrap(errs.Config, err)
+	}
+	return filepath.Join(dir, "pins.json"), nil
+}
+
+/*
+updatePins changes the pin registry. The registry is only used by the
+"pins" commands, so failing to update it is reported, but is not an error.
+*/
+func updatePins(fn func(r *pins.Registry)) {
+	path, err := pinsPath()
+	if err == nil {
+		err = pins.Update(path, func(r *pins.Registry) error {
+			fn(r)
+			return nil
+		})
+	}
+	if err != nil {
+		output.Status("pins", output.Fields{"error": err.Error()}, "[!] Failed to update the pin registry: %v", err)
+	}
+}
+
+// filePins returns the pins of the file with metadata cid, for the active profile.
+func filePins(cid string, meta *lemon3libs.Lemon3Metadata) []pins.Pin {
+	p := []pins.Pin{
+		{Cid: meta.Enclosed["/"], Role: pins.Enclosure, Size: meta.Size},
+		{Cid: meta.Artwork["/"], Role: pins.Artwork},
+		{Cid: meta.Thumbnail["/"], Role: pins.Thumbnail},
+		{Cid: cid, Role: pins.Metadata},
+	}
+	result := []pins.Pin{}
+	for _, pin := range p {
+		if pin.Cid != "" {
+			pin.Metadata = cid
+			pin.Profile = config.ActiveProfile()
+			result = append(result, pin)
+		}
+	}
+	return result
+}
+
+// pinFile pins the metadata cid and the enclosure, artwork and thumbnail it names, and adds them to the registry.
+func pinFile(cid string, meta *lemon3libs.Lemon3Metadata) error {
+	pinList := filePins(cid, meta)
+	for _, p := range pinList {
+		if err := ipfsclient.PinCID(p.Cid); err != nil {
+			return fmt.Errorf("failed to pin %s: %w", p.Role, err)
+		}
+	}
+	updatePins(func(r *pins.Registry) {
+		for _, p := range pinList {
+			r.Add(p)
+		}
+	})
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(pinsCmd)
+}
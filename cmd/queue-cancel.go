@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/errs"
+	"github.com/vrypan/lemon3/output"
+	"github.com/vrypan/lemon3/queue"
+)
+
+var queuecancelCmd = &cobra.Command{
+	Use:   "cancel <id>...",
+	Short: "Cancel scheduled casts",
+	Long: `Removes casts from the queue before they are posted.
+
+The uploaded files stay pinned on your IPFS node.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: queue_cancel,
+}
+
+func queue_cancel(cmd *cobra.Command, args []string) error {
+	path, err := queuePath()
+	if err != nil {
+		return err
+	}
+	cancelled := []string{}
+	err = queue.Update(path, func(q *queue.Queue) error {
+		for _, id := range args {
+			item, err := q.Find(id)
+			if err != nil {
+				return err
+			}
+			switch item.Status {
+			case queue.Published:
+				return errs.New(errs.UserInput, "%s was already published as %s", item.Id, item.Cast)
+			case queue.Sending:
+				return errs.New(errs.UserInput, "%s is being posted right now", item.Id)
+			}
+			q.Remove(item.Id)
+			cancelled = append(cancelled, item.Id)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, id := range cancelled {
+		output.Status("cancelled", output.Fields{"id": id}, "[-] %s cancelled", id)
+	}
+	output.Result(output.Fields{"cancelled": cancelled}, "[✓] %d scheduled cast(s) cancelled", len(cancelled))
+	return nil
+}
+
+func init() {
+	queueCmd.AddCommand(queuecancelCmd)
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/errs"
+	"github.com/vrypan/lemon3/fcclient"
+	"github.com/vrypan/lemon3/lemon3libs"
+	"github.com/vrypan/lemon3/output"
+	"github.com/vrypan/lemon3/queue"
+)
+
+var queueeditCmd = &cobra.Command{
+	Use:   "edit <id>",
+	Short: "Change the time, text or channel of a scheduled cast",
+	Long: `Change the time, text or channel of a scheduled cast.
+
+Editing a cast that failed puts it back in the queue.`,
+	Example: `lemon3 queue edit 3f2a --at "2026-11-02T09:00Z" --text "Episode 2 is out"`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    queue_edit,
+}
+
+func queue_edit(cmd *cobra.Command, args []string) error {
+	var at time.Time
+	if s, _ := cmd.Flags().GetString("at"); s != "" {
+		var err error
+		if at, err = lemon3libs.ParseTime(s); err != nil {
+			return errs.Wrap(errs.UserInput, err)
+		}
+	}
+	var parentUrl string
+	channel, _ := cmd.Flags().GetString("channel")
+	if channel != "" {
+		config.Load()
+		api := config.GetString("farcaster.api")
+		if api == "" {
+			api = fcclient.DefaultSignerApi
+		}
+		var err error
+		if parentUrl, err = fcclient.ChannelUrl(api, channel); err != nil {
+			return err
+		}
+	}
+
+	path, err := queuePath()
+	if err != nil {
+		return err
+	}
+	var edited queue.Item
+	err = queue.Update(path, func(q *queue.Queue) error {
+		item, err := q.Find(args[0])
+		if err != nil {
+			return err
+		}
+		switch item.Status {
+		case queue.Published:
+			return errs.New(errs.UserInput, "%s was already published as %s", item.Id, item.Cast)
+		case queue.Sending:
+			return errs.New(errs.UserInput, "%s is being posted right now", item.Id)
+		}
+		if !at.IsZero() {
+			item.At = at
+		}
+		if cmd.Flags().Changed("text") {
+			item.Text, _ = cmd.Flags().GetString("text")
+		}
+		if cmd.Flags().Changed("channel") {
+			item.Channel, item.ParentUrl = channel, parentUrl
+		}
+		if item.Status == queue.Failed {
+			item.Status = queue.Pending
+			item.Attempts = 0
+		}
+		edited = *item
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	output.Result(output.Fields{"item": edited}, "[✓] %s scheduled for %s", edited.Id, edited.At.Local().Format("2006-01-02 15:04"))
+	return nil
+}
+
+func init() {
+	queueCmd.AddCommand(queueeditCmd)
+	queueeditCmd.Flags().String("at", "", "New time, for example 2026-11-01T09:00Z or \"2026-11-01 09:00\" (local time)")
+	queueeditCmd.Flags().String("text", "", "New cast text")
+	queueeditCmd.Flags().String("channel", "", "New channel (channel id, or parent URL), empty for none")
+}
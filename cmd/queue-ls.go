@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/output"
+	"github.com/vrypan/lemon3/queue"
+)
+
+var queuelsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List scheduled casts",
+	RunE:  queue_ls,
+}
+
+func queue_ls(cmd *cobra.Command, args []string) error {
+	all, _ := cmd.Flags().GetBool("all")
+	path, err := queuePath()
+	if err != nil {
+		return err
+	}
+	q, err := queue.Load(path)
+	if err != nil {
+		return err
+	}
+
+	items := []*queue.Item{}
+	var table strings.Builder
+	w := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tAT\tSTATUS\tPROFILE\tCHANNEL\tTITLE\tCAST")
+	for _, item := range q.Items {
+		if item.Status == queue.Published && !all {
+			continue
+		}
+		items = append(items, item)
+		title := item.Title
+		if title == "" {
+			title = item.Filename
+		}
+		cast := item.Cast
+		if item.Status != queue.Published && item.Error != "" {
+			cast = fmt.Sprintf("%d attempt(s), %s", item.Attempts, item.Error)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", item.Id, item.At.Local().Format("2006-01-02 15:04"),
+			item.Status, item.Profile, item.Channel, title, cast)
+	}
+	w.Flush()
+
+	text := strings.TrimSuffix(table.String(), "\n")
+	if len(items) == 0 {
+		text = "No scheduled casts."
+	}
+	output.Result(output.Fields{"items": items}, "%s", text)
+	return nil
+}
+
+func init() {
+	queueCmd.AddCommand(queuelsCmd)
+	queuelsCmd.Flags().Bool("all", false, "Also list the casts already published")
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/errs"
+	"github.com/vrypan/lemon3/output"
+	"github.com/vrypan/lemon3/queue"
+)
+
+var queuerunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Post the scheduled casts that are due",
+	Long: `Post the scheduled casts that are due, for every profile.
+
+A cast that can not be posted is tried again on the next run, and
+marked failed after a few attempts. Run it from cron, or use
+"lemon3 daemon" to keep it running.`,
+	RunE: queue_run,
+}
+
+func queue_run(cmd *cobra.Command, args []string) error {
+	runner := &queueRunner{publishers: map[string]*publisher{}}
+	items, err := runner.run()
+	if err != nil {
+		return err
+	}
+	published, failed := 0, 0
+	for _, item := range items {
+		if item.Status == queue.Published {
+			published++
+		} else {
+			failed++
+		}
+	}
+	output.Result(
+		output.Fields{"items": items, "published": published, "failed": failed},
+		"[✓] %d scheduled cast(s) posted, %d failed", published, failed,
+	)
+	if failed > 0 {
+		return errs.New(errs.Network, "%d scheduled cast(s) could not be posted, see \"lemon3 queue ls\"", failed)
+	}
+	return nil
+}
+
+func init() {
+	queueCmd.AddCommand(queuerunCmd)
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/errs"
+	"github.com/vrypan/lemon3/output"
+	"github.com/vrypan/lemon3/queue"
+)
+
+var queueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "Manage scheduled casts",
+	Long: `Manage the casts scheduled with "lemon3 upload --at".
+
+Files are uploaded and pinned when they are scheduled. "lemon3 queue run"
+posts the casts that are due, and "lemon3 daemon" keeps doing it in the
+background. Casts are posted with the profile they were scheduled with.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// queuePath returns the path of the queue, shared by all profiles.
+func queuePath() (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", errs.Wrap(errs.Config, err)
+	}
+	return filepath.Join(dir, "queue.json"), nil
+}
+
+// enqueue schedules the cast of an uploaded file for the active profile.
+func enqueue(pub *publisher, up uploadResult, text, channel, parentUrl string, at time.Time) (*queue.Item, error) {
+	path, err := queuePath()
+	if err != nil {
+		return nil, err
+	}
+	item := &queue.Item{
+		Profile:   config.ActiveProfile(),
+		At:        at,
+		Text:      text,
+		Channel:   channel,
+		ParentUrl: parentUrl,
+		Previews:  pub.previews,
+		Metadata:  up.Metadata,
+		Filename:  up.Filename,
+		Title:     up.Title,
+	}
+	err = queue.Update(path, func(q *queue.Queue) error {
+		q.Add(item)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save the queue: %w", err)
+	}
+	output.Status("queued", output.Fields{"id": item.Id, "at": at.Format(time.RFC3339), "metadata": up.Metadata},
+		"[+] Cast scheduled for %s (queue id %s)", at.Local().Format("2006-01-02 15:04"), item.Id)
+	return item, nil
+}
+
+/*
+queueRunner posts the scheduled casts that are due. Publishers are kept
+between runs, so that "lemon3 daemon" asks for each passphrase only once.
+*/
+type queueRunner struct {
+	publishers map[string]*publisher
+	stop       <-chan struct{} // closed to stop after the item being cast
+}
+
+func (r *queueRunner) stopped() bool {
+	select {
+	case <-r.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// run posts the casts due now, and returns the items it tried.
+func (r *queueRunner) run() ([]*queue.Item, error) {
+	path, err := queuePath()
+	if err != nil {
+		return nil, err
+	}
+	q, err := queue.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		// Back to the profile selected with --profile.
+		config.SetProfile(profile)
+		config.Load()
+	}()
+
+	tried := []*queue.Item{}
+	for _, p := range q.Profiles(time.Now()) {
+		if r.stopped() {
+			break
+		}
+		pub, ok := r.publishers[p]
+		if !ok {
+			config.SetProfile(p)
+			if !config.ProfileExists(p) || config.Load() == "" {
+				output.Status("queue", output.Fields{"profile": p, "error": "profile is not set up"}, "[!] Profile %s is not set up", p)
+				continue
+			}
+			if pub, err = newPublisher(nil); err != nil {
+				output.Status("queue", output.Fields{"profile": p, "error": err.Error()}, "[!] Profile %s: %v", p, err)
+				continue
+			}
+			r.publishers[p] = pub
+		}
+
+		var due []*queue.Item
+		err := queue.Update(path, func(q *queue.Queue) error {
+			due = q.Claim(p, time.Now())
+			return nil
+		})
+		if err != nil {
+			return tried, err
+		}
+		for i, item := range due {
+			if r.stopped() {
+				return tried, release(path, due[i:])
+			}
+			output.Status("queue", output.Fields{"id": item.Id, "profile": p}, "[^] Posting %s (%s)", item.Id, item.Filename)
+			itemPub := *pub
+			itemPub.previews = item.Previews
+			castHash, castErr := itemPub.cast(item.Text, uploadResult{Filename: item.Filename, Metadata: item.Metadata}, item.ParentUrl)
+			cast := ""
+			if castErr == nil {
+				cast = fmt.Sprintf("@%s/0x%s", pub.username, castHash)
+			} else {
+				output.Status("queue", output.Fields{"id": item.Id, "error": castErr.Error()}, "[!] %s: %v", item.Id, castErr)
+			}
+			err := queue.Update(path, func(q *queue.Queue) error {
+				saved, err := q.Find(item.Id)
+				if err != nil {
+					return nil // cancelled while we were posting it
+				}
+				saved.Done(cast, castErr, time.Now())
+				*item = *saved
+				return nil
+			})
+			if err != nil {
+				return tried, err
+			}
+			tried = append(tried, item)
+		}
+	}
+	return tried, nil
+}
+
+// release gives back the claimed items that were not cast.
+func release(path string, items []*queue.Item) error {
+	return queue.Update(path, func(q *queue.Queue) error {
+		for _, item := range items {
+			if saved, err := q.Find(item.Id); err == nil {
+				saved.Release()
+			}
+		}
+		return nil
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(queueCmd)
+}
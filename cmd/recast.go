@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/fcclient"
+	"github.com/vrypan/lemon3/lemon3libs"
+)
+
+var recastCmd = &cobra.Command{
+	Use:   "recast <cast>",
+	Short: "Post a lemon3 cast of your own for the file of another cast",
+	Long: `Post a cast with your own text and the lemon3 embeds of the file
+shared in another cast, usually someone else's.
+
+The file is pinned on the local node and announced, so that you help
+seed it, unless --no-pin is set. The cast can be @user/<hash>,
+fid:<fid>/<hash> or a farcaster.xyz cast URL.`,
+	Example: `lemon3 recast @vrypan.eth/0xcd3141a4 --text "Great episode" --channel music`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    recast,
+}
+
+func recast(cmd *cobra.Command, args []string) error {
+	configFile := config.Load()
+	if configFile == "" {
+		return errNoSetup
+	}
+	ref, err := lemon3libs.ParseCastRef(args[0])
+	if err != nil {
+		return err
+	}
+	hub, err := fcclient.NewFarcasterHub(hubConfig())
+	if err != nil {
+		return err
+	}
+	_, cid, err := resolveCastRef(hub, ref)
+	hub.Close()
+	if err != nil {
+		return err
+	}
+	noPin, _ := cmd.Flags().GetBool("no-pin")
+	return castExisting(cmd, cid, !noPin)
+}
+
+func init() {
+	rootCmd.AddCommand(recastCmd)
+	addCastFlags(recastCmd)
+	recastCmd.Flags().Bool("no-pin", false, "Do not pin the file on the local node")
+}
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/errs"
+	"github.com/vrypan/lemon3/fcclient"
+	"github.com/vrypan/lemon3/ipfsclient"
+	"github.com/vrypan/lemon3/lemon3libs"
+	"github.com/vrypan/lemon3/output"
+	"github.com/vrypan/lemon3/pins"
+	"github.com/vrypan/lemon3/queue"
+)
+
+var rmCmd = &cobra.Command{
+	Use:   "rm <cast>",
+	Short: "Remove a cast you posted, and optionally unpin its files",
+	Long: `Remove a lemon3 cast you posted, with a cast remove message signed
+with your app key.
+
+With --unpin, the metadata, the enclosed file, the artwork and the
+thumbnail are also unpinned on the local IPFS node, except the ones your
+other lemon3 casts, or the casts in the queue, still use. Unpinned
+content is deleted by the next "ipfs repo gc". Other nodes that pinned
+the files keep them.
+
+If the metadata of one of your other casts can not be found within
+--timeout, nothing is unpinned, since it may use the same files.`,
+	Example: `lemon3 rm @alice/0xcd3141a4 --unpin`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    rm,
+}
+
+func rm(cmd *cobra.Command, args []string) error {
+	configFile := config.Load()
+	if configFile == "" {
+		return errNoSetup
+	}
+	ref, err := lemon3libs.ParseCastRef(args[0])
+	if err != nil {
+		return err
+	}
+	unpin, _ := cmd.Flags().GetBool("unpin")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+
+	pub, err := newPublisher(nil)
+	if err != nil {
+		return err
+	}
+	if err := ipfsclient.Init(config.GetString("ipfs.hub")); err != nil {
+		return err
+	}
+	cast, cid, err := resolveOwnCast(pub, ref)
+	if err != nil {
+		return err
+	}
+	hash := fmt.Sprintf("0x%x", cast.Hash)
+
+	// Find what to unpin before removing the cast, so that nothing is removed if this fails.
+	var unpins []string
+	kept := []string{}
+	if unpin {
+		meta, err := lemon3libs.FromCid(cid)
+		if err != nil {
+			return err
+		}
+		used, unchecked, err := referencedCids(cast.Data.Fid, cast.Hash, timeout)
+		if err != nil {
+			return fmt.Errorf("unable to check which files your other casts use, nothing was removed: %w", err)
+		}
+		for _, c := range unchecked {
+			output.Status("unchecked", output.Fields{"metadata": c},
+				"[!] %s was not found within %s, keeping all files pinned in case it uses them.", c, timeout)
+		}
+		for _, c := range []string{cid, meta.Enclosed["/"], meta.Artwork["/"], meta.Thumbnail["/"]} {
+			switch {
+			case c == "":
+			case used[c] || len(unchecked) > 0:
+				kept = append(kept, c)
+			default:
+				unpins = append(unpins, c)
+			}
+		}
+	}
+
+	if _, err := fcclient.RemoveCast(pub.hubConf, pub.username, pub.key, cast.Hash); err != nil {
+		return err
+	}
+	output.Status("removed", output.Fields{"hash": hash}, "[-] Removed @%s/%s", pub.username, hash)
+
+	unpinned := []string{}
+	for _, c := range unpins {
+		if err := ipfsclient.UnpinCID(c); err != nil {
+			return fmt.Errorf("the cast was removed, but %s could not be unpinned: %w", c, err)
+		}
+		unpinned = append(unpinned, c)
+		output.Status("unpinned", output.Fields{"cid": c}, "[-] %s unpinned.", c)
+	}
+	if unpin {
+		updatePins(func(r *pins.Registry) {
+			r.RemoveMetadata(cid)
+			for _, c := range unpinned {
+				r.Remove(c)
+			}
+		})
+	}
+	for _, c := range kept {
+		output.Status("kept", output.Fields{"cid": c}, "[+] %s is used by another cast, keeping it pinned.", c)
+	}
+	output.Result(
+		output.Fields{"removed": hash, "metadata": cid, "unpinned": unpinned, "kept": kept},
+		"[✓] @%s/%s removed", pub.username, hash,
+	)
+	return nil
+}
+
+/*
+referencedCids returns the metadata of the lemon3 casts of fid, except
+skip, and of the casts waiting in the queue, with the CIDs it links to.
+Metadata that can not be fetched within timeout is returned as unchecked.
+*/
+func referencedCids(fid uint64, skip []byte, timeout time.Duration) (used map[string]bool, unchecked []string, err error) {
+	used = map[string]bool{}
+	addMetadata := func(cid string) error {
+		used[cid] = true
+		meta, err := lemon3libs.FromCidTimeout(cid, timeout)
+		if errs.KindOf(err) == errs.Network {
+			unchecked = append(unchecked, cid)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for _, c := range []string{meta.Enclosed["/"], meta.Artwork["/"], meta.Thumbnail["/"]} {
+			if c != "" {
+				used[c] = true
+			}
+		}
+		return nil
+	}
+
+	path, err := queuePath()
+	if err != nil {
+		return nil, nil, err
+	}
+	q, err := queue.Load(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, item := range q.Items {
+		if item.Status == queue.Pending || item.Status == queue.Sending {
+			if err := addMetadata(item.Metadata); err != nil {
+				return nil, nil, fmt.Errorf("queued cast %s: %w", item.Id, err)
+			}
+		}
+	}
+
+	hub, err := fcclient.NewFarcasterHub(hubConfig())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer hub.Close()
+	scanned := 0
+	defer func() {
+		if scanned > 0 {
+			output.EndProgress()
+		}
+	}()
+	var pageToken []byte
+	for {
+		page, err := hub.GetCastsByFidPage(fid, 100, true, pageToken)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, msg := range page.Messages {
+			scanned++
+			output.Progress(output.Fields{"scanned": scanned}, "[^] Checking your casts: %d", scanned)
+			cid := lemon3libs.CidFromMessage(msg)
+			if cid == "" || bytes.Equal(msg.Hash, skip) {
+				continue
+			}
+			if err := addMetadata(cid); err != nil {
+				return nil, nil, fmt.Errorf("0x%x: %w", msg.Hash, err)
+			}
+		}
+		if len(page.NextPageToken) == 0 || len(page.Messages) == 0 {
+			return used, unchecked, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(rmCmd)
+	rmCmd.Flags().Bool("unpin", false, "Also unpin the metadata, file and artwork on the local IPFS node")
+	rmCmd.Flags().Duration("timeout", 30*time.Second, "With --unpin, how long to search the network for the metadata of each of your other casts")
+}
@@ -7,10 +7,20 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/errs"
+	"github.com/vrypan/lemon3/fcclient"
+	"github.com/vrypan/lemon3/output"
 )
 
 var Version string
 
+var profile string
+
+var outputFormat string
+
+var errNoSetup = errs.New(errs.Config, "please run \"lemon3 setup\" first")
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "lemon3",
@@ -24,6 +34,12 @@ to quickly create a Cobra application.`,
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	// Run: func(cmd *cobra.Command, args []string) { },
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return errs.Wrap(errs.UserInput, output.SetFormat(outputFormat))
+	},
+	// Errors are printed by Execute, using the selected output format.
+	SilenceErrors: true,
+	SilenceUsage:  true,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -31,7 +47,17 @@ to quickly create a Cobra application.`,
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
-		os.Exit(1)
+		output.Error(err)
+		os.Exit(errs.ExitCode(err))
+	}
+}
+
+// hubConfig returns the Farcaster node settings from the configuration.
+func hubConfig() fcclient.HubConfig {
+	return fcclient.HubConfig{
+		Host: config.GetString("farcaster.node.address"),
+		Ssl:  config.GetString("farcaster.node.ssl") == "true",
+		Key:  config.GetString("farcaster.node.apikey"),
 	}
 }
 
@@ -41,6 +67,14 @@ func init() {
 	// will be global for your application.
 
 	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.lemon3.yaml)")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "Configuration profile to use (default $LEMON3_PROFILE, or the one selected with \"config profiles use\")")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", output.Text, "Output format: text, or json (NDJSON)")
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return errs.Wrap(errs.UserInput, err)
+	})
+	cobra.OnInitialize(func() {
+		config.SetProfile(profile)
+	})
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/errs"
+	"github.com/vrypan/lemon3/ipfsclient"
+	"github.com/vrypan/lemon3/lemon3libs"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve HTML/OpenGraph preview pages for lemon3 uploads",
+	Long: `Serve the preview pages linked from lemon3 casts, using the
+local IPFS node to resolve metadata and artwork.
+
+GET /<cid>          preview page of the lemon3 metadata <cid>
+GET /<cid>/artwork  the artwork image
+
+To link casts to your server, set the preview URL template (only one is
+allowed), for example
+lemon3 config set preview.urls "https://preview.example.com/{cid}"`,
+	RunE: serve,
+}
+
+func serve(cmd *cobra.Command, args []string) error {
+	configFile := config.Load()
+	if configFile == "" {
+		return errNoSetup
+	}
+	listen, _ := cmd.Flags().GetString("listen")
+	baseUrl, _ := cmd.Flags().GetString("base-url")
+	gateway, _ := cmd.Flags().GetString("gateway")
+	if baseUrl == "" {
+		baseUrl = "http://" + listen
+	}
+	baseUrl = strings.TrimSuffix(baseUrl, "/")
+	gateway = strings.TrimSuffix(gateway, "/")
+
+	if err := ipfsclient.Init(config.GetString("ipfs.hub")); err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{cid}/artwork", func(w http.ResponseWriter, r *http.Request) {
+		meta, err := lemon3libs.FromCid(r.PathValue("cid"))
+		if err != nil || meta.Artwork["/"] == "" {
+			http.NotFound(w, r)
+			return
+		}
+		data, err := ipfsclient.CatCID(meta.Artwork["/"])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", http.DetectContentType(data))
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		w.Write(data)
+	})
+	previewHandler := func(w http.ResponseWriter, r *http.Request) {
+		cid := r.PathValue("cid")
+		meta, err := lemon3libs.FromCid(cid)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		page := lemon3libs.PreviewPage{
+			Cid:     cid,
+			Meta:    meta,
+			PageUrl: baseUrl + "/" + cid,
+			FileUrl: fmt.Sprintf("%s/ipfs/%s?filename=%s", gateway, meta.Enclosed["/"], url.QueryEscape(meta.Filename)),
+		}
+		if meta.Artwork["/"] != "" {
+			page.ArtworkUrl = baseUrl + "/" + cid + "/artwork"
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := lemon3libs.RenderPreview(w, page); err != nil {
+			log.Printf("[!] Failed to render %s: %v\n", cid, err)
+		}
+	}
+	mux.HandleFunc("GET /{cid}", previewHandler)
+	mux.HandleFunc("GET /{cid}/{filename}", previewHandler)
+
+	fmt.Printf("[+] Serving previews on http://%s\n", listen)
+	if err := http.ListenAndServe(listen, mux); err != nil {
+		return errs.Wrap(errs.Network, err)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().String("listen", "127.0.0.1:8080", "Address to listen on")
+	serveCmd.Flags().String("base-url", "", "Public URL of the server, used in OpenGraph tags (default http://<listen>)")
+	serveCmd.Flags().String("gateway", "https://ipfs.io", "IPFS gateway used for file links")
+}
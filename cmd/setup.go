@@ -2,21 +2,40 @@ package cmd
 
 import (
 	"bufio"
+	"crypto/ed25519"
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/vrypan/lemon3/appkey"
 	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/errs"
+	"github.com/vrypan/lemon3/fcclient"
+	"github.com/vrypan/lemon3/ipfsclient"
+	"golang.org/x/term"
 )
 
 type ConfigEntry struct {
 	Key         string
 	Default     string
 	Description string
+	Validate    func(string) error
+}
+
+func (e ConfigEntry) check(value string) error {
+	if e.Validate == nil {
+		return nil
+	}
+	return e.Validate(value)
 }
 
 func getDesktopPath() string {
@@ -58,81 +77,284 @@ func defaultDownloadDir() string {
 	return filepath.Join(getDesktopPath(), "lemon3")
 }
 
+// setupEntries returns the configuration keys set by "lemon3 setup".
+func setupEntries() []ConfigEntry {
+	return []ConfigEntry{
+		{
+			Key:     "farcaster.node.address",
+			Default: "",
+			Description: `Farcaster node, in host:port format. Port is usually 3383
+To use Neynar nodes, check out the instructions at https://github.com/vrypan/lemon3`,
+			Validate: validateHostPort,
+		},
+		{
+			Key:         "farcaster.node.ssl",
+			Default:     "false",
+			Description: "Use SSL? Enter 'true' or 'false'",
+			Validate:    validateBool,
+		},
+		{
+			Key:         "farcaster.node.apikey",
+			Default:     "",
+			Description: "If you use a hub provided by Neynar or similar services, enter your API key",
+		},
+		{
+			Key:         "farcaster.account.fname",
+			Default:     "",
+			Description: "Your Farcaster username (fname)",
+			Validate:    validateFname,
+		},
+		{
+			Key:         "ipfs.hub",
+			Default:     "http://127.0.0.1:5001/api/v0",
+			Description: "IPFS API endpoint (usually your local Kubo node)",
+			Validate:    validateUrl,
+		},
+		{
+			Key:         "download.dir",
+			Default:     defaultDownloadDir(),
+			Description: "Directory where downloads will be saved",
+			Validate:    validateNotEmpty,
+		},
+	}
+}
+
+func validateNotEmpty(v string) error {
+	if v == "" {
+		return errors.New("value is required")
+	}
+	return nil
+}
+
+func validateHostPort(v string) error {
+	host, port, err := net.SplitHostPort(v)
+	if err != nil {
+		return fmt.Errorf("expected host:port: %w", err)
+	}
+	if host == "" {
+		return errors.New("host is missing")
+	}
+	if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
+
+func validateBool(v string) error {
+	if v != "true" && v != "false" {
+		return errors.New("enter 'true' or 'false'")
+	}
+	return nil
+}
+
+var fnameRegexp = regexp.MustCompile(`^([a-z0-9][a-z0-9-]{0,15}|[a-z0-9][a-z0-9.-]*\.eth)$`)
+
+func validateFname(v string) error {
+	if !fnameRegexp.MatchString(v) {
+		return fmt.Errorf("%q is not a valid fname", v)
+	}
+	return nil
+}
+
+func validateUrl(v string) error {
+	u, err := url.Parse(v)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("expected an http(s)://host:port/... URL")
+	}
+	return nil
+}
+
+// flagName returns the setup flag for a configuration key, for example --farcaster-node-address.
+func flagName(key string) string {
+	return strings.ReplaceAll(key, ".", "-")
+}
+
+// Environment variable with the app key, for "setup --non-interactive".
+const envAppKey = "LEMON3_APPKEY"
+
+/*
+readSetupAppKey reads the app key from stdin with --appkey-stdin,
+LEMON3_APPKEY, or a prompt. There is no flag for the key itself, since
+it would show in ps and the shell history. It returns nil if no key was
+entered, to keep the current one.
+*/
+func readSetupAppKey(cmd *cobra.Command, nonInteractive bool, reader *bufio.Reader) (ed25519.PrivateKey, error) {
+	fromStdin, _ := cmd.Flags().GetBool("appkey-stdin")
+	switch {
+	case fromStdin:
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			return nil, errs.New(errs.UserInput, "failed to read the app key from stdin: %w", err)
+		}
+		return appkey.Parse(line)
+	case os.Getenv(envAppKey) != "":
+		return appkey.Parse(os.Getenv(envAppKey))
+	case nonInteractive:
+		return nil, nil
+	}
+
+	fmt.Println()
+	fmt.Println("App key used to authenticate with the Farcaster Hub, stored encrypted with a passphrase.")
+	fmt.Println("You can create one at https://www.castkeys.xyz, or with \"lemon3 signer new\".")
+	fd := int(os.Stdin.Fd())
+	for {
+		fmt.Print("App key [leave empty to keep the current one]: ")
+		var input string
+		if term.IsTerminal(fd) {
+			b, err := term.ReadPassword(fd)
+			fmt.Println()
+			if err != nil {
+				return nil, err
+			}
+			input = string(b)
+		} else {
+			input, _ = reader.ReadString('\n')
+		}
+		if strings.TrimSpace(input) == "" {
+			return nil, nil
+		}
+		key, err := appkey.Parse(input)
+		if err != nil {
+			fmt.Printf("[!] %v\n", err)
+			continue
+		}
+		return key, nil
+	}
+}
+
+// checkConnectivity tests the hub and IPFS settings before they are saved.
+func checkConnectivity() error {
+	hub, err := fcclient.NewFarcasterHub(hubConfig())
+	if err != nil {
+		return err
+	}
+	defer hub.Close()
+	info, err := hub.GetInfo()
+	if err != nil {
+		return fmt.Errorf("Farcaster node %s: %w", config.GetString("farcaster.node.address"), err)
+	}
+	fmt.Printf("[✓] Farcaster node %s (version %s)\n", config.GetString("farcaster.node.address"), info.Version)
+	if err := ipfsclient.Ping(config.GetString("ipfs.hub")); err != nil {
+		return fmt.Errorf("IPFS node %s: %w", config.GetString("ipfs.hub"), err)
+	}
+	fmt.Printf("[✓] IPFS node %s\n", config.GetString("ipfs.hub"))
+	return nil
+}
+
 var setupCmd = &cobra.Command{
 	Use:   "setup",
 	Short: "Configure lemon3",
-	Run: func(cmd *cobra.Command, args []string) {
+	Long: `Configure lemon3 interactively.
 
-		configs := []ConfigEntry{
-			{
-				Key:     "farcaster.node.address",
-				Default: "",
-				Description: `Farcaster node, in host:port format. Port is usually 3383
-To use Neynar nodes, check out the instructions at https://github.com/vrypan/lemon3`,
-			},
-			{
-				Key:         "farcaster.node.ssl",
-				Default:     "false",
-				Description: "Use SSL? Enter 'true' or 'false'",
-			},
-			{
-				Key:         "farcaster.node.apikey",
-				Default:     "",
-				Description: "If you use a hub provided by Neynar or similar services, enter your API key",
-			},
-			{
-				Key:         "farcaster.account.fname",
-				Default:     "",
-				Description: "Your Farcaster username (fname)",
-			},
-			{
-				Key:         "farcaster.account.appkey",
-				Default:     "",
-				Description: "App key used to authenticate with the Farcaster Hub.\nYou can create one at https://www.castkeys.xyz",
-			},
-			{
-				Key:         "ipfs.hub",
-				Default:     "http://127.0.0.1:5001/api/v0",
-				Description: "IPFS API endpoint (usually your local Kubo node)",
-			},
-			{
-				Key:         "download.dir",
-				Default:     defaultDownloadDir(),
-				Description: "Directory where downloads will be saved",
-			},
-		}
+With --non-interactive, values are taken from the flags below,
+LEMON3_* environment variables (for example LEMON3_FARCASTER_NODE_ADDRESS),
+the existing configuration, or the defaults, in this order.
+
+The connection to the Farcaster and IPFS nodes is tested before
+saving, use --skip-check to save anyway.
 
+The app key is read from LEMON3_APPKEY, or from stdin with --appkey-stdin,
+and stored encrypted with the passphrase in LEMON3_PASSPHRASE, or
+entered when asked.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := config.CreateProfileDir(config.ActiveProfile()); err != nil {
+			return errs.Wrap(errs.Config, err)
+		}
 		configFile := config.Load()
+		nonInteractive, _ := cmd.Flags().GetBool("non-interactive")
+		skipCheck, _ := cmd.Flags().GetBool("skip-check")
+
 		reader := bufio.NewReader(os.Stdin)
-		for _, entry := range configs {
-			fmt.Println()
-			fmt.Printf("%s\n", entry.Description)
+		for _, entry := range setupEntries() {
 			value := entry.Default
 			if v := config.GetString(entry.Key); v != "" {
 				value = v
 			}
-			fmt.Printf("%s [%s]: ", entry.Key, value)
-
-			input, _ := reader.ReadString('\n')
-			input = strings.TrimSpace(input)
+			if cmd.Flags().Changed(flagName(entry.Key)) {
+				value, _ = cmd.Flags().GetString(flagName(entry.Key))
+			}
 
-			if input == "" {
+			if nonInteractive {
+				if err := entry.check(value); err != nil {
+					return errs.New(errs.Config, "%s: %w", entry.Key, err)
+				}
 				viper.Set(entry.Key, value)
-			} else {
+				continue
+			}
+
+			fmt.Println()
+			fmt.Printf("%s\n", entry.Description)
+			for {
+				fmt.Printf("%s [%s]: ", entry.Key, value)
+				input, _ := reader.ReadString('\n')
+				input = strings.TrimSpace(input)
+				if input == "" {
+					input = value
+				}
+				if err := entry.check(input); err != nil {
+					fmt.Printf("[!] %v\n", err)
+					continue
+				}
 				viper.Set(entry.Key, input)
+				break
+			}
+		}
+
+		key, err := readSetupAppKey(cmd, nonInteractive, reader)
+		if err != nil {
+			return err
+		}
+		var passphrase []byte
+		if key != nil {
+			if _, ok := os.LookupEnv(appkey.EnvPassphrase); !ok && !term.IsTerminal(int(os.Stdin.Fd())) {
+				return errs.New(errs.UserInput, "set %s to encrypt the app key", appkey.EnvPassphrase)
+			}
+			if passphrase, err = appkey.ReadNewPassphrase(); err != nil {
+				return err
+			}
+			// Replaced by the encrypted key.
+			viper.Set("farcaster.account.appkey", "")
+		}
+
+		// Not asked by setup, but saved with the other values.
+		if err := fcclient.CheckPreviewUrls(config.GetStringSlice("preview.urls")); err != nil {
+			return errs.New(errs.Config, "preview.urls: %w, fix it with \"lemon3 config set preview.urls <url>\"", err)
+		}
+
+		if !skipCheck {
+			fmt.Println()
+			if err := checkConnectivity(); err != nil {
+				fmt.Println("Configuration not saved. Fix the settings above, or use --skip-check.")
+				return errs.Wrap(errs.Network, err)
 			}
 		}
 
 		// Save configuration
 		fmt.Printf("\nSaving configuration to %s...\n", configFile)
 		if err := viper.WriteConfigAs(configFile); err != nil {
-			fmt.Println("Error writing config:", err)
-		} else {
-			fmt.Println("Configuration saved.")
+			return errs.New(errs.Config, "failed to write config: %w", err)
 		}
+		fmt.Println("Configuration saved.")
+		if key != nil {
+			path, err := saveEncryptedKey(key, passphrase)
+			if err != nil {
+				return errs.Wrap(errs.Config, err)
+			}
+			fmt.Printf("Encrypted app key saved to %s\n", path)
+		}
+		return nil
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(setupCmd)
+	setupCmd.Flags().Bool("non-interactive", false, "Do not prompt, use flags, environment variables and defaults")
+	setupCmd.Flags().Bool("skip-check", false, "Save without testing the connection to the Farcaster and IPFS nodes")
+	setupCmd.Flags().Bool("appkey-stdin", false, "Read the app key from stdin")
+	for _, entry := range setupEntries() {
+		setupCmd.Flags().String(flagName(entry.Key), "", strings.SplitN(entry.Description, "\n", 2)[0])
+	}
 }
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/appkey"
+	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/fcclient"
+)
+
+var signernewCmd = &cobra.Command{
+	Use:   "new",
+	Short: "Generate a new app key and print the signed key request to approve",
+	RunE:  signer_new,
+}
+
+func signer_new(cmd *cobra.Command, args []string) error {
+	configFile := config.Load()
+	if configFile == "" {
+		return errNoSetup
+	}
+	force, _ := cmd.Flags().GetBool("force")
+	if pending, err := appkey.LoadPending(); err == nil && !force {
+		return fmt.Errorf("key 0x%s is already waiting for approval. Use --force to replace it.", pending.PublicKey)
+	}
+
+	fid, _ := cmd.Flags().GetUint64("fid")
+	if fid == 0 {
+		username := config.GetString("farcaster.account.fname")
+		hub, err := fcclient.NewFarcasterHub(hubConfig())
+		if err != nil {
+			return err
+		}
+		defer hub.Close()
+		if fid, err = hub.GetFidByUsername(username); err != nil {
+			return fmt.Errorf("unable to get FID for %s: %w", username, err)
+		}
+	}
+	requestFid, _ := cmd.Flags().GetUint64("request-fid")
+	if requestFid == 0 {
+		requestFid = fid
+	}
+	validity, _ := cmd.Flags().GetDuration("deadline")
+	deadline := time.Now().Add(validity).Unix()
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+	passphrase, err := appkey.ReadNewPassphrase()
+	if err != nil {
+		return err
+	}
+	encrypted, err := appkey.Encrypt(private, passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt key: %w", err)
+	}
+	req := appkey.SignerRequest{
+		PublicKey:  hex.EncodeToString(public),
+		Fid:        fid,
+		RequestFid: requestFid,
+		Deadline:   deadline,
+	}
+	if err := appkey.SavePending(encrypted, req); err != nil {
+		return fmt.Errorf("failed to save key: %w", err)
+	}
+
+	typedData, _ := json.MarshalIndent(fcclient.SignedKeyRequestTypedData(requestFid, public, deadline), "", "  ")
+	fmt.Printf("[+] New app key: 0x%x\n\n", public)
+	fmt.Printf("Sign this EIP-712 message with the custody address of FID %d:\n\n%s\n\n", requestFid, typedData)
+	fmt.Println("Then run:")
+	fmt.Println("  lemon3 signer request --signature 0x<signature>")
+	fmt.Println("and approve the request. \"lemon3 signer status\" will save the key when it is active.")
+	return nil
+}
+
+func init() {
+	signerCmd.AddCommand(signernewCmd)
+	signernewCmd.Flags().Uint64("fid", 0, "FID the key is for (default: FID of farcaster.account.fname)")
+	signernewCmd.Flags().Uint64("request-fid", 0, "FID of the app requesting the key (default: --fid)")
+	signernewCmd.Flags().Duration("deadline", 24*time.Hour, "How long the signed key request is valid")
+	signernewCmd.Flags().Bool("force", false, "Replace a key that is waiting for approval")
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/appkey"
+	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/errs"
+	"github.com/vrypan/lemon3/fcclient"
+)
+
+var signerrequestCmd = &cobra.Command{
+	Use:   "request",
+	Short: "Create the approval deeplink for the new app key",
+	Long: `Submit the signed key request of the key created by "lemon3 signer new"
+and print the deeplink used to approve it in a Farcaster client.
+
+With --request-signer, the ABI-encoded SignedKeyRequestMetadata is also
+printed, for registering the key directly with KeyGateway.add().`,
+	RunE: signer_request,
+}
+
+func signer_request(cmd *cobra.Command, args []string) error {
+	config.Load()
+	req, err := appkey.LoadPending()
+	if err != nil {
+		return err
+	}
+	s, _ := cmd.Flags().GetString("signature")
+	signature, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil || len(signature) != 65 {
+		return errs.New(errs.UserInput, "--signature must be a 65-byte hex encoded signature")
+	}
+	key, _ := hex.DecodeString(req.PublicKey)
+
+	if s, _ := cmd.Flags().GetString("request-signer"); s != "" {
+		address, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+		if err != nil {
+			return fmt.Errorf("invalid request signer: %w", err)
+		}
+		metadata, err := fcclient.SignedKeyRequestMetadata(req.RequestFid, address, signature, req.Deadline)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("KeyGateway.add(1, 0x%x, 1, 0x%x)\n\n", key, metadata)
+		if noSubmit, _ := cmd.Flags().GetBool("no-submit"); noSubmit {
+			return nil
+		}
+	}
+
+	api, _ := cmd.Flags().GetString("api")
+	token, deeplink, err := fcclient.SubmitSignedKeyRequest(api, req.RequestFid, key, signature, req.Deadline)
+	if err != nil {
+		return err
+	}
+	req.Token = token
+	req.DeeplinkUrl = deeplink
+	if err := req.Save(); err != nil {
+		return fmt.Errorf("failed to save request: %w", err)
+	}
+	fmt.Printf("Open this link to approve the key:\n\n  %s\n\n", deeplink)
+	fmt.Println("Then run \"lemon3 signer status\".")
+	return nil
+}
+
+func init() {
+	signerCmd.AddCommand(signerrequestCmd)
+	signerrequestCmd.Flags().String("signature", "", "EIP-712 signature of the signed key request")
+	signerrequestCmd.Flags().String("request-signer", "", "Custody address that signed the request")
+	signerrequestCmd.Flags().Bool("no-submit", false, "Only print the KeyGateway.add() parameters")
+	signerrequestCmd.Flags().String("api", fcclient.DefaultSignerApi, "Farcaster client API")
+	signerrequestCmd.MarkFlagRequired("signature")
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/vrypan/lemon3/appkey"
+	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/errs"
+	"github.com/vrypan/lemon3/fcclient"
+)
+
+var signerstatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Wait until the new app key is active and save it",
+	RunE:  signer_status,
+}
+
+func signer_status(cmd *cobra.Command, args []string) error {
+	config.Load()
+	req, err := appkey.LoadPending()
+	if err != nil {
+		return err
+	}
+	key, _ := hex.DecodeString(req.PublicKey)
+	interval, _ := cmd.Flags().GetDuration("interval")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+
+	hub, err := fcclient.NewFarcasterHub(hubConfig())
+	if err != nil {
+		return err
+	}
+	defer hub.Close()
+
+	start := time.Now()
+	for {
+		active, err := hub.IsActiveSigner(req.Fid, key)
+		if err != nil {
+			fmt.Println()
+			return fmt.Errorf("failed to get signers of FID %d: %w", req.Fid, err)
+		}
+		if active {
+			break
+		}
+		if time.Since(start) >= timeout {
+			fmt.Println()
+			if req.DeeplinkUrl != "" {
+				fmt.Printf("Approve it at %s\n", req.DeeplinkUrl)
+			}
+			return errs.New(errs.Auth, "key 0x%s is not active yet", req.PublicKey)
+		}
+		fmt.Printf("\r[|] Waiting for 0x%s to become active (%s)", req.PublicKey, time.Since(start).Round(time.Second))
+		time.Sleep(interval)
+	}
+	fmt.Printf("\r[✓] Key 0x%s is active.                          \n", req.PublicKey)
+
+	path, err := appkey.ActivatePending()
+	if err != nil {
+		return fmt.Errorf("failed to save key: %w", err)
+	}
+	fmt.Printf("[+] Saved app key to %s\n", path)
+	if config.GetString("farcaster.account.appkey") != "" {
+		viper.Set("farcaster.account.appkey", "")
+		if err := viper.WriteConfig(); err != nil {
+			fmt.Printf("[!] Failed to remove farcaster.account.appkey from config: %v\n", err)
+		}
+	}
+	if config.GetString("farcaster.account.appkey_cmd") != "" {
+		fmt.Println("[!] farcaster.account.appkey_cmd is set and takes precedence over the new key.")
+	}
+	return nil
+}
+
+func init() {
+	signerCmd.AddCommand(signerstatusCmd)
+	signerstatusCmd.Flags().Duration("interval", 10*time.Second, "Time between checks")
+	signerstatusCmd.Flags().Duration("timeout", 30*time.Minute, "Stop waiting after this long")
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var signerCmd = &cobra.Command{
+	Use:   "signer",
+	Short: "Create and register a new app key",
+	Long: `Create a new app key locally and register it as a signer of your account.
+
+1. "lemon3 signer new" generates the key and prints the EIP-712 message
+   that the custody address of the requesting FID must sign.
+2. "lemon3 signer request --signature 0x..." creates the approval deeplink.
+3. "lemon3 signer status" waits until the key is active on the hub and
+   saves it as your app key.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(signerCmd)
+}
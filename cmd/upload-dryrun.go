@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"encoding/json"
+
+	"github.com/vrypan/lemon3/fcclient"
+	"github.com/vrypan/lemon3/output"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+/*
+uploadDryRun prints the metadata of up and the signed message that
+would cast it, without submitting the message.
+*/
+func uploadDryRun(pub *publisher, up uploadResult, text string, parentUrl string) error {
+	message, err := fcclient.CastMessage(pub.hubConf, pub.username, pub.key, text, up.Metadata, up.Filename, pub.previews, parentUrl)
+	if err != nil {
+		return err
+	}
+	msgJson, err := protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(message)
+	if err != nil {
+		return err
+	}
+	dagJson, err := json.MarshalIndent(up.Dag, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	result := output.Fields{
+		"dry_run":  true,
+		"metadata": up.Metadata,
+		"enclosed": up.Enclosed,
+		"dag":      json.RawMessage(dagJson),
+		"message":  json.RawMessage(msgJson),
+	}
+	if up.Artwork != "" {
+		result["artwork"] = up.Artwork
+	}
+	if up.Thumbnail != "" {
+		result["thumbnail"] = up.Thumbnail
+	}
+	output.Result(
+		result,
+		"\nMetadata (cid=%s):\n%s\n\nCast message (not submitted):\n%s\n\n[-] Dry run, nothing was uploaded, pinned or cast.",
+		up.Metadata, dagJson, msgJson,
+	)
+	return nil
+}
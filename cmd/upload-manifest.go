@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/errs"
+	"github.com/vrypan/lemon3/ipfsclient"
+	"github.com/vrypan/lemon3/lemon3libs"
+	"github.com/vrypan/lemon3/output"
+	"github.com/vrypan/lemon3/queue"
+)
+
+/*
+uploadManifest uploads and casts the entries of a manifest in order.
+Each step is recorded in the journal of the manifest, so that running
+the same command again skips what is already done. Entries scheduled
+in the future are uploaded, and their casts are added to the queue.
+*/
+func uploadManifest(cmd *cobra.Command, path string) error {
+	manifest, err := lemon3libs.LoadManifest(path)
+	if err != nil {
+		return err
+	}
+	journal, err := lemon3libs.LoadJournal(path)
+	if err != nil {
+		return errs.Wrap(errs.UserInput, err)
+	}
+
+	// Check every entry before uploading anything.
+	flags := uploadRequestFromFlags(cmd, "")
+	if flags.RemotePins, err = remotePinServices(cmd); err != nil {
+		return err
+	}
+	defaultCast, _ := cmd.Flags().GetString("cast")
+	requests := make([]uploadRequest, len(manifest.Entries))
+	files := make([]os.FileInfo, len(manifest.Entries))
+	for i, e := range manifest.Entries {
+		req := uploadRequest{
+			File:       manifest.FilePath(e),
+			Title:      e.Title,
+			Artwork:    e.Artwork,
+			NoExtract:  flags.NoExtract,
+			NoArtwork:  flags.NoArtwork,
+			RawArtwork: flags.RawArtwork,
+
+			RemotePins:       flags.RemotePins,
+			RemotePinTimeout: flags.RemotePinTimeout,
+		}
+		if files[i], err = os.Stat(req.File); err != nil {
+			return errs.New(errs.UserInput, "manifest entry %d: %w", i+1, err)
+		}
+		if req.Artwork != "" {
+			if _, err := os.Stat(req.Artwork); err != nil {
+				return errs.New(errs.UserInput, "manifest entry %d: %w", i+1, err)
+			}
+		}
+		if req.Description, err = readDescription(e.Description); err != nil {
+			return fmt.Errorf("manifest entry %d: %w", i+1, err)
+		}
+		requests[i] = req
+	}
+
+	pub, err := publisherFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+	parentUrls := make([]string, len(manifest.Entries))
+	for i, e := range manifest.Entries {
+		if parentUrls[i], err = pub.channelUrl(e.Channel); err != nil {
+			return fmt.Errorf("manifest entry %d: %w", i+1, err)
+		}
+	}
+	if err := ipfsclient.Init(config.GetString("ipfs.hub")); err != nil {
+		return err
+	}
+
+	qPath, err := queuePath()
+	if err != nil {
+		return err
+	}
+	q, err := queue.Load(qPath)
+	if err != nil {
+		return err
+	}
+
+	results := []output.Fields{}
+	published, scheduled, failed := 0, 0, 0
+	for i, e := range manifest.Entries {
+		label := fmt.Sprintf("[%d/%d] %s", i+1, len(manifest.Entries), e.File)
+		result := output.Fields{"file": e.File}
+		results = append(results, result)
+		resume := fmt.Sprintf("run \"lemon3 upload --manifest %s\" again to resume", path)
+
+		entry := journal.Entries[e.File]
+		if entry != nil && entry.Queued != "" && entry.Cast == "" {
+			if item, err := q.Find(entry.Queued); err == nil {
+				switch item.Status {
+				case queue.Published:
+					entry.Cast = item.Cast
+					entry.CastAt = item.CastAt
+					if err := journal.Save(); err != nil {
+						return fmt.Errorf("failed to save %s: %w", journal.Path(), err)
+					}
+				case queue.Failed:
+					// Retrying is up to the user, who may want to change the time or the text first.
+					result["status"] = "failed"
+					result["metadata"] = entry.Metadata
+					result["queued"] = item.Id
+					result["error"] = item.Error
+					output.Status("failed", result, "[!] %s: the scheduled cast failed (%s), run \"lemon3 queue edit %s\" to try again",
+						label, item.Error, item.Id)
+					failed++
+					continue
+				default:
+					result["status"] = "queued"
+					result["metadata"] = entry.Metadata
+					result["queued"] = item.Id
+					output.Status("skip", result, "[-] %s: already scheduled for %s (queue id %s)",
+						label, item.At.Local().Format("2006-01-02 15:04"), item.Id)
+					scheduled++
+					continue
+				}
+			}
+		}
+		if entry != nil && entry.Cast != "" {
+			result["status"] = "done"
+			result["metadata"] = entry.Metadata
+			result["cast"] = entry.Cast
+			output.Status("skip", result, "[-] %s: already published as %s", label, entry.Cast)
+			continue
+		}
+
+		var up uploadResult
+		if entry = journal.Uploaded(e.File, files[i]); entry != nil {
+			up = uploadResult{
+				Title:     e.Title,
+				Filename:  entry.Filename,
+				Enclosed:  entry.Enclosed,
+				Artwork:   entry.Artwork,
+				Thumbnail: entry.Thumbnail,
+				Metadata:  entry.Metadata,
+			}
+			output.Status("resume", output.Fields{"file": e.File, "metadata": up.Metadata},
+				"[+] %s: already uploaded, metadata cid=%s", label, up.Metadata)
+		} else {
+			output.Status("entry", output.Fields{"file": e.File, "index": i + 1}, "[^] %s", label)
+			if up, err = uploadFile(requests[i]); err != nil {
+				return fmt.Errorf("%s: %w\n%s", label, err, resume)
+			}
+			entry = &lemon3libs.JournalEntry{
+				Size:      files[i].Size(),
+				Modified:  files[i].ModTime(),
+				Filename:  up.Filename,
+				Enclosed:  up.Enclosed,
+				Artwork:   up.Artwork,
+				Thumbnail: up.Thumbnail,
+				Metadata:  up.Metadata,
+			}
+			journal.Entries[e.File] = entry
+			if err := journal.Save(); err != nil {
+				return fmt.Errorf("failed to save %s: %w", journal.Path(), err)
+			}
+		}
+		result["metadata"] = up.Metadata
+
+		text := e.Cast
+		if text == "" {
+			text = defaultCast
+		}
+		if e.Time.After(time.Now()) {
+			item, err := enqueue(pub, up, text, e.Channel, parentUrls[i], e.Time)
+			if err != nil {
+				return fmt.Errorf("%s: %w\n%s", label, err, resume)
+			}
+			entry.Queued = item.Id
+			if err := journal.Save(); err != nil {
+				return fmt.Errorf("failed to save %s: %w", journal.Path(), err)
+			}
+			result["status"] = "queued"
+			result["queued"] = item.Id
+			result["at"] = e.Time.Format(time.RFC3339)
+			scheduled++
+			continue
+		}
+
+		castHash, err := pub.cast(text, up, parentUrls[i])
+		if err != nil {
+			return fmt.Errorf("%s: %w\n%s", label, err, resume)
+		}
+		entry.Cast = fmt.Sprintf("@%s/0x%s", pub.username, castHash)
+		entry.CastAt = time.Now()
+		if err := journal.Save(); err != nil {
+			return fmt.Errorf("failed to save %s: %w", journal.Path(), err)
+		}
+		result["status"] = "published"
+		result["cast"] = entry.Cast
+		published++
+	}
+
+	output.Result(
+		output.Fields{"entries": results, "published": published, "scheduled": scheduled, "failed": failed, "journal": journal.Path()},
+		"\n[✓] %d of %d entries published now, %d scheduled, %d failed. Progress is saved in %s",
+		published, len(manifest.Entries), scheduled, failed, journal.Path(),
+	)
+	if failed > 0 {
+		return errs.New(errs.Network, "%d scheduled casts failed, see \"lemon3 queue ls --all\"", failed)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/errs"
+	"github.com/vrypan/lemon3/ipfsclient"
+	"github.com/vrypan/lemon3/output"
+	"github.com/vrypan/lemon3/pinning"
+	"github.com/vrypan/lemon3/pins"
+)
+
+/*
+remotePinServices returns the remote pinning services of the profile
+(pinning.<name>.endpoint and pinning.<name>.token), or the ones selected
+with --remote-pin, or none with --no-remote-pin.
+*/
+func remotePinServices(cmd *cobra.Command) ([]*pinning.Service, error) {
+	if noRemote, _ := cmd.Flags().GetBool("no-remote-pin"); noRemote {
+		return nil, nil
+	}
+	names := []string{}
+	for name := range config.GetStringMap("pinning") {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if cmd.Flags().Changed("remote-pin") {
+		names, _ = cmd.Flags().GetStringSlice("remote-pin")
+	}
+	services := []*pinning.Service{}
+	for _, name := range names {
+		endpoint := config.GetString("pinning." + name + ".endpoint")
+		if endpoint == "" {
+			return nil, errs.New(errs.Config, "pinning service %s is not configured, set pinning.%s.endpoint", name, name)
+		}
+		services = append(services, &pinning.Service{
+			Name:     name,
+			Endpoint: endpoint,
+			Token:    config.GetString("pinning." + name + ".token"),
+		})
+	}
+	return services, nil
+}
+
+/*
+remotePin asks every service to pin the CIDs of an uploaded file, and
+waits until they all have. The services fetch the content from the
+network, so the local node must stay online until then.
+*/
+func remotePin(services []*pinning.Service, filePins []pins.Pin, name string, timeout time.Duration) error {
+	if len(services) == 0 {
+		return nil
+	}
+	origins := []string{}
+	if id, err := ipfsclient.Id(); err == nil {
+		origins = ipfsclient.PublicAddresses(id)
+	}
+	requests := make([]pinning.Pin, len(filePins))
+	for i, p := range filePins {
+		requests[i] = pinning.Pin{
+			Cid:     p.Cid,
+			Name:    fmt.Sprintf("%s (%s)", name, p.Role),
+			Origins: origins,
+			Meta:    map[string]string{"app": "lemon3", "role": p.Role},
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	start := time.Now()
+	last := map[string]string{} // status of each request, to report changes only
+	progressing := false
+	err := pinning.PinAll(ctx, services, requests, 5*time.Second, func(s *pinning.Service, status *pinning.PinStatus) {
+		key := s.Name + " " + status.Pin.Cid
+		if last[key] == status.Status {
+			output.Progress(output.Fields{"event": "remote-pin", "service": s.Name, "cid": status.Pin.Cid, "status": status.Status},
+				"[^] %s: %s is %s (%s)", s.Name, status.Pin.Cid, status.Status, time.Since(start).Round(time.Second))
+			progressing = true
+			return
+		}
+		last[key] = status.Status
+		if progressing {
+			output.EndProgress()
+			progressing = false
+		}
+		if status.Status == pinning.Pinned {
+			output.Status("remote-pinned", output.Fields{"service": s.Name, "cid": status.Pin.Cid}, "[+] %s pinned on %s.", status.Pin.Cid, s.Name)
+			return
+		}
+		output.Status("remote-pin", output.Fields{"service": s.Name, "cid": status.Pin.Cid, "request": status.RequestId, "status": status.Status},
+			"[^] %s: %s is %s", s.Name, status.Pin.Cid, status.Status)
+	})
+	if progressing {
+		output.EndProgress()
+	}
+	if err != nil {
+		return fmt.Errorf("remote pinning failed: %w", err)
+	}
+	return nil
+}
@@ -3,6 +3,8 @@ package cmd
 import (
 	"fmt"
 	"io"
+	"math"
+	"mime"
 	"strings"
 	"time"
 
@@ -11,103 +13,333 @@ import (
 	"path/filepath"
 
 	"github.com/spf13/cobra"
+	"github.com/vrypan/lemon3/appkey"
+	lemon3artwork "github.com/vrypan/lemon3/artwork"
 	"github.com/vrypan/lemon3/config"
+	"github.com/vrypan/lemon3/errs"
 	"github.com/vrypan/lemon3/fcclient"
 	"github.com/vrypan/lemon3/ipfsclient"
+	"github.com/vrypan/lemon3/lemon3libs"
+	"github.com/vrypan/lemon3/mediainfo"
+	"github.com/vrypan/lemon3/output"
+	"github.com/vrypan/lemon3/pinning"
+	"github.com/vrypan/lemon3/pins"
 )
 
 // uploadCmd represents the upload command
 var uploadCmd = &cobra.Command{
 	Use:   "upload <file>",
 	Short: "Uploads file to ipfs, and creates a cast with lemon3 embeds",
-	Run:   upload,
+	Long: `Uploads file to ipfs, and creates a cast with lemon3 embeds.
+
+With --manifest, uploads every file listed in a YAML manifest:
+
+  defaults:
+    artwork: cover.jpg
+    channel: podcasts
+  entries:
+    - file: ep01.mp3
+      title: Episode 1
+      description: "@ep01.txt"
+      cast: Episode 1 is out!
+      at: 2026-11-01T09:00Z
+
+Progress is saved in <manifest>.journal. If the upload fails, run the same
+command again to continue without uploading the completed entries again.
+
+With --at, or an "at" time in a manifest, files are uploaded right away and
+their casts are added to the queue (see "lemon3 queue").
+
+Files are also pinned on the remote pinning services configured with
+"lemon3 config set pinning.<name>.endpoint <url>" and pinning.<name>.token
+(IPFS Pinning Service API), and upload waits until they are pinned.
+
+With --dry-run, nothing is uploaded, pinned or cast: the CIDs of the file,
+its artwork and its metadata are computed locally, and the metadata and
+the signed cast message are printed for review.`,
+	RunE: upload,
+}
+
+// uploadRequest describes a file to upload. Empty fields are read from the file.
+type uploadRequest struct {
+	File        string
+	Title       string
+	Name        string // file name stored in the metadata
+	Mime        string
+	Description string
+	Artwork     string
+	NoExtract   bool
+	NoArtwork   bool
+	RawArtwork  bool
+	DryRun      bool // compute the CIDs without storing anything
+
+	RemotePins       []*pinning.Service
+	RemotePinTimeout time.Duration
 }
 
-func upload(cmd *cobra.Command, args []string) {
+// uploadResult holds the CIDs of an uploaded file.
+type uploadResult struct {
+	Title     string
+	Filename  string
+	Enclosed  string
+	Artwork   string
+	Thumbnail string
+	Metadata  string
+	Dag       map[string]any // the metadata object
+}
+
+func upload(cmd *cobra.Command, args []string) error {
 	configFile := config.Load()
 	if configFile == "" {
-		fmt.Println("Please run \"lemon3 setup\" first.")
-		return
+		return errNoSetup
 	}
 
+	if manifest, _ := cmd.Flags().GetString("manifest"); manifest != "" {
+		if len(args) > 0 {
+			return errs.New(errs.UserInput, "use either a file or --manifest")
+		}
+		if req := uploadRequestFromFlags(cmd, ""); req.DryRun {
+			return errs.New(errs.UserInput, "--dry-run can not be used with --manifest")
+		}
+		return uploadManifest(cmd, manifest)
+	}
 	if len(args) == 0 {
-		cmd.Help()
-		return
+		return cmd.Help()
 	}
 
-	artwork, _ := cmd.Flags().GetString("artwork")
-	if artwork == "" {
-		fmt.Println("You need to provide an artwork file (jpeg, or png)")
-		return
+	req := uploadRequestFromFlags(cmd, args[0])
+	if _, err := os.Stat(req.File); err != nil {
+		return errs.Wrap(errs.UserInput, err)
 	}
-
-	ipfsclient.Init(config.GetString("ipfs.hub"))
-	fmt.Println()
-
-	// Upload file
-	fpath := args[0]
-	cid, err := ipfsclient.AddFile(fpath)
+	var err error
+	if req.Description, err = readDescription(req.Description); err != nil {
+		return err
+	}
+	if req.RemotePins, err = remotePinServices(cmd); err != nil {
+		return err
+	}
+	pub, err := publisherFromFlags(cmd)
 	if err != nil {
-		panic(err)
+		return err
 	}
-
-	err = ipfsclient.PinCID(cid)
+	channel, _ := cmd.Flags().GetString("channel")
+	parentUrl, err := pub.channelUrl(channel)
 	if err != nil {
-		fmt.Println("\n[!] Failed to pin data!")
-		return
+		return err
+	}
+	var at time.Time
+	if s, _ := cmd.Flags().GetString("at"); s != "" {
+		if at, err = lemon3libs.ParseTime(s); err != nil {
+			return errs.Wrap(errs.UserInput, err)
+		}
+		if !at.After(time.Now()) {
+			return errs.New(errs.UserInput, "--at %s is in the past, leave it out to cast now", at.Local().Format("2006-01-02 15:04"))
+		}
 	}
-	fmt.Printf("[+] %s pinned.\n", cid)
 
-	// Upload artwork
-	artworkCid, err := ipfsclient.AddFile(artwork)
+	if err := ipfsclient.Init(config.GetString("ipfs.hub")); err != nil {
+		return err
+	}
+	up, err := uploadFile(req)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	err = ipfsclient.PinCID(artworkCid)
+
+	castText, _ := cmd.Flags().GetString("cast")
+	if req.DryRun {
+		return uploadDryRun(pub, up, castText, parentUrl)
+	}
+	if at.After(time.Now()) {
+		item, err := enqueue(pub, up, castText, channel, parentUrl, at)
+		if err != nil {
+			return err
+		}
+		output.Result(
+			output.Fields{"queued": item.Id, "at": at.Format(time.RFC3339), "metadata": up.Metadata, "enclosed": up.Enclosed},
+			"\nThe cast will be posted by \"lemon3 queue run\" or \"lemon3 daemon\" after %s.", at.Local().Format("2006-01-02 15:04"),
+		)
+		return nil
+	}
+	castHash, err := pub.cast(castText, up, parentUrl)
 	if err != nil {
-		fmt.Println("\n[!] Failed to pin artwork!")
-		return
+		return err
+	}
+
+	result := output.Fields{
+		"cast":     fmt.Sprintf("@%s/0x%s", pub.username, castHash),
+		"hash":     "0x" + castHash,
+		"url":      fmt.Sprintf("https://farcaster.xyz/%s/0x%s", pub.username, castHash),
+		"metadata": up.Metadata,
+		"enclosed": up.Enclosed,
+	}
+	if up.Artwork != "" {
+		result["artwork"] = up.Artwork
+	}
+	if up.Thumbnail != "" {
+		result["thumbnail"] = up.Thumbnail
+	}
+	output.Result(
+		result,
+		"\nView cast: https://farcaster.xyz/%s/0x%s", pub.username, castHash,
+	)
+	return nil
+}
+
+// uploadRequestFromFlags returns the upload of file, with the settings given as flags.
+func uploadRequestFromFlags(cmd *cobra.Command, file string) uploadRequest {
+	req := uploadRequest{File: file}
+	req.Title, _ = cmd.Flags().GetString("title")
+	req.Name, _ = cmd.Flags().GetString("name")
+	req.Mime, _ = cmd.Flags().GetString("mime")
+	req.Description, _ = cmd.Flags().GetString("description")
+	req.Artwork, _ = cmd.Flags().GetString("artwork")
+	req.NoExtract, _ = cmd.Flags().GetBool("no-extract")
+	req.NoArtwork, _ = cmd.Flags().GetBool("no-artwork")
+	req.RawArtwork, _ = cmd.Flags().GetBool("raw-artwork")
+	req.DryRun, _ = cmd.Flags().GetBool("dry-run")
+	req.RemotePinTimeout, _ = cmd.Flags().GetDuration("remote-pin-timeout")
+	return req
+}
+
+// readDescription returns the description, or the text it points to if it is @file or @- (stdin).
+func readDescription(description string) (string, error) {
+	source, ok := strings.CutPrefix(description, "@")
+	if !ok {
+		return description, nil
+	}
+	var data []byte
+	var err error
+	if source == "-" {
+		data, err = io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("error reading description from stdin: %w", err)
+		}
+	} else {
+		data, err = os.ReadFile(source)
+		if err != nil {
+			return "", errs.New(errs.UserInput, "error reading description file: %w", err)
+		}
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+/*
+uploadFile uploads and pins a file, its artwork and its metadata, and
+announces the metadata. Missing title, description, artwork and type are
+read from the file, and artwork is generated if there is none.
+With req.DryRun, the CIDs are computed and nothing is stored.
+*/
+func uploadFile(req uploadRequest) (uploadResult, error) {
+	fpath := req.File
+	info := &mediainfo.Info{}
+	if !req.NoExtract {
+		var err error
+		if info, err = mediainfo.Extract(fpath); err != nil {
+			output.Status("extract", output.Fields{"error": err.Error()}, "[!] Unable to read metadata from %s: %v", fpath, err)
+			info = &mediainfo.Info{}
+		}
+	}
+
+	artwork := req.Artwork
+	if artwork == "" && len(info.Artwork) > 0 {
+		// Upload the cover art embedded in the file.
+		var err error
+		if artwork, err = writeTempArtwork(info.Artwork); err != nil {
+			return uploadResult{}, err
+		}
+		defer os.Remove(artwork)
+		output.Status("artwork", output.Fields{"source": "embedded"}, "[+] Using the cover art embedded in %s", fpath)
 	}
-	fmt.Printf("[+] %s pinned.\n", artworkCid)
 
-	mimeType, err := detectMimeType(fpath)
+	mimeType := info.Mime
+	if mimeType == "" {
+		mimeType, _ = detectMimeType(fpath)
+	}
 	fileSize, err := getFileSize(fpath)
+	if err != nil {
+		return uploadResult{}, errs.Wrap(errs.UserInput, err)
+	}
 	fileName := filepath.Base(fpath)
 	fileTitle := fileName
-	fileDescription := ""
-
-	var s string
-	if s, _ = cmd.Flags().GetString("title"); s != "" {
-		fileTitle = s
+	if info.Title != "" {
+		fileTitle = info.Title
+	}
+	if req.Title != "" {
+		fileTitle = req.Title
 	}
-	if s, _ = cmd.Flags().GetString("name"); s != "" {
-		fileName = s
+	if req.Name != "" {
+		fileName = req.Name
 	}
-	if s, _ = cmd.Flags().GetString("mime"); s != "" {
-		mimeType = s
+	if req.Mime != "" {
+		mimeType = req.Mime
+	}
+	fileDescription := req.Description
+	if fileDescription == "" {
+		fileDescription = info.Description
 	}
 
-	fileDescription, _ = cmd.Flags().GetString("description")
-	if strings.HasPrefix(fileDescription, "@") {
-		source := strings.TrimPrefix(fileDescription, "@")
+	if artwork == "" && !req.NoArtwork {
+		// Generate a card with the title, filename, type and size.
+		png, err := lemon3artwork.Placeholder(lemon3artwork.Card{
+			Title: fileTitle, Filename: fileName, Type: mimeType, Size: fileSize,
+		})
+		if err != nil {
+			return uploadResult{}, fmt.Errorf("failed to generate artwork: %w", err)
+		}
+		if artwork, err = writeTempArtwork(png); err != nil {
+			return uploadResult{}, err
+		}
+		defer os.Remove(artwork)
+		output.Status("artwork", output.Fields{"source": "placeholder"}, "[+] No artwork given, using a generated placeholder")
+	}
 
-		var data []byte
-		var err error
+	// Resize the artwork, and remove EXIF and other metadata from it.
+	var cover, thumb lemon3artwork.Image
+	var thumbnail string
+	if artwork != "" && !req.RawArtwork {
+		original, err := os.ReadFile(artwork)
+		if err != nil {
+			return uploadResult{}, errs.Wrap(errs.UserInput, err)
+		}
+		cover, thumb, err = lemon3artwork.Normalize(original, config.GetInt("artwork.size"), config.GetInt("artwork.thumbnail_size"))
+		if err != nil {
+			// Uploading the original would publish its EXIF and GPS data.
+			return uploadResult{}, errs.New(errs.UserInput,
+				"unable to process artwork %s: %w. Convert it to JPEG, PNG or GIF, or use --raw-artwork to upload it unchanged, with its metadata", artwork, err)
+		}
+		if artwork, err = writeTempArtwork(cover.Data); err != nil {
+			return uploadResult{}, err
+		}
+		defer os.Remove(artwork)
+		if thumbnail, err = writeTempArtwork(thumb.Data); err != nil {
+			return uploadResult{}, err
+		}
+		defer os.Remove(thumbnail)
+		output.Status("artwork", output.Fields{"width": cover.Width, "height": cover.Height, "thumbnail_width": thumb.Width, "thumbnail_height": thumb.Height},
+			"[+] Artwork %dx%d, thumbnail %dx%d", cover.Width, cover.Height, thumb.Width, thumb.Height)
+	}
 
-		if source == "-" {
-			data, err = io.ReadAll(os.Stdin)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error reading description from stdin: %v\n", err)
-				os.Exit(1)
-			}
-		} else {
-			data, err = os.ReadFile(source)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error reading description file: %v\n", err)
-				os.Exit(1)
-			}
+	up := uploadResult{Title: fileTitle, Filename: fileName}
+	add := addAndPin
+	if req.DryRun {
+		add = hashOnly
+	}
+
+	// Upload file
+	if up.Enclosed, err = add(fpath, "enclosure"); err != nil {
+		return up, err
+	}
+
+	// Upload artwork
+	if artwork != "" {
+		if up.Artwork, err = add(artwork, "artwork"); err != nil {
+			return up, err
+		}
+	}
+	if thumbnail != "" {
+		if up.Thumbnail, err = add(thumbnail, "thumbnail"); err != nil {
+			return up, err
 		}
-		fileDescription = strings.TrimSpace(string(data))
 	}
 
 	data := map[string]any{
@@ -116,48 +348,161 @@ func upload(cmd *cobra.Command, args []string) {
 		"type":        mimeType,
 		"filename":    fileName,
 		"size":        fileSize,
-		"enclosed":    map[string]string{"/": cid},
-		"artwork":     map[string]string{"/": artworkCid},
+		"enclosed":    map[string]string{"/": up.Enclosed},
+	}
+	if up.Artwork != "" {
+		data["artwork"] = map[string]string{"/": up.Artwork}
+	}
+	if cover.Width > 0 {
+		data["artwork_width"] = cover.Width
+		data["artwork_height"] = cover.Height
+	}
+	if up.Thumbnail != "" {
+		data["thumbnail"] = map[string]string{"/": up.Thumbnail}
+		data["thumbnail_width"] = thumb.Width
+		data["thumbnail_height"] = thumb.Height
+	}
+	if info.Duration > 0 {
+		data["duration"] = math.Round(info.Duration.Seconds()*1000) / 1000
+	}
+	up.Dag = data
+	if req.DryRun {
+		if up.Metadata, err = ipfsclient.DagCid(data); err != nil {
+			return up, fmt.Errorf("failed to compute the metadata CID: %w", err)
+		}
+		output.Status("metadata", output.Fields{"cid": up.Metadata, "stored": false}, "[^] Metadata cid=%s (not stored)", up.Metadata)
+		return up, nil
 	}
 	dagCid, err := ipfsclient.DagPut(data)
 	if err != nil {
-		fmt.Println("\n[!] Failed to upload metadate!")
-		return
+		return up, fmt.Errorf("failed to upload metadata: %w", err)
 	}
-	fmt.Printf("[^] Metadata cid=%s\n", dagCid)
+	output.Status("metadata", output.Fields{"cid": dagCid}, "[^] Metadata cid=%s", dagCid)
 
 	err = ipfsclient.PinCID(dagCid)
 	if err != nil {
-		fmt.Println("\n[!] Failed to pin metadata!")
-		return
+		return up, fmt.Errorf("failed to pin metadata: %w", err)
 	}
 	err = ipfsclient.ProvideCIDRecursive(dagCid)
 	if err != nil {
-		fmt.Println("\n[!] Failed to announce metadata.")
-		return
+		return up, fmt.Errorf("failed to announce metadata: %w", err)
 	}
-	fmt.Printf("[+] %s pinned.\n", dagCid)
+	output.Status("pinned", output.Fields{"cid": dagCid, "role": "metadata"}, "[+] %s pinned.", dagCid)
+	up.Metadata = dagCid
 
-	if WaitForCID(dagCid, 5, 10) != nil {
-		fmt.Println("Exiting")
-		return
+	pinList := filePins(dagCid, &lemon3libs.Lemon3Metadata{
+		Size:      fileSize,
+		Enclosed:  map[string]string{"/": up.Enclosed},
+		Artwork:   map[string]string{"/": up.Artwork},
+		Thumbnail: map[string]string{"/": up.Thumbnail},
+	})
+	for i, p := range pinList {
+		switch p.Role {
+		case pins.Artwork:
+			pinList[i].Size, _ = getFileSize(artwork)
+		case pins.Thumbnail:
+			pinList[i].Size, _ = getFileSize(thumbnail)
+		}
 	}
+	updatePins(func(r *pins.Registry) {
+		for _, p := range pinList {
+			r.Add(p)
+		}
+	})
 
-	hubConf := fcclient.HubConfig{
-		Host: config.GetString("farcaster.node.address"),
-		Ssl:  config.GetString("farcaster.node.ssl") == "true",
-		Key:  config.GetString("farcaster.node.apikey"),
+	if err := remotePin(req.RemotePins, pinList, fileName, req.RemotePinTimeout); err != nil {
+		return up, err
 	}
-	fmt.Println(hubConf)
-	username := config.GetString("farcaster.account.fname")
-	userkey := config.GetString("farcaster.account.appkey")
+	return up, nil
+}
+
+// publisher posts lemon3 casts with the account in the configuration.
+type publisher struct {
+	hubConf  fcclient.HubConfig
+	username string
+	fid      uint64
+	key      appkey.Source
+	previews []string // preview URL templates
+	channels map[string]string
+}
 
-	castText, err := cmd.Flags().GetString("cast")
-	castHash := fcclient.Cast(hubConf, username, userkey, castText, dagCid)
-	fmt.Printf("[^] Cast posted: @%s/0x%s\n", username, castHash)
+/*
+publisherFromFlags loads the app key and checks it can cast, before
+anything is uploaded, so we don't ask for a passphrase after a long
+upload, or fail because the key is missing. Preview URLs are set with
+--preview and --no-preview.
+*/
+func publisherFromFlags(cmd *cobra.Command) (*publisher, error) {
+	previews := config.GetStringSlice("preview.urls")
+	if cmd.Flags().Changed("preview") {
+		previews, _ = cmd.Flags().GetStringSlice("preview")
+	}
+	if noPreview, _ := cmd.Flags().GetBool("no-preview"); noPreview {
+		previews = nil
+	}
+	if err := fcclient.CheckPreviewUrls(previews); err != nil {
+		return nil, errs.Wrap(errs.UserInput, err)
+	}
+	return newPublisher(previews)
+}
+
+// newPublisher returns a publisher for the account of the loaded profile.
+func newPublisher(previews []string) (*publisher, error) {
+	keySource, err := appkey.FromConfig()
+	if err != nil {
+		return nil, err
+	}
+	key, err := keySource.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load app key: %w", err)
+	}
+	pub := &publisher{
+		hubConf:  hubConfig(),
+		username: config.GetString("farcaster.account.fname"),
+		key:      appkey.Key(key),
+		previews: previews,
+		channels: map[string]string{},
+	}
+	if pub.fid, err = fcclient.CheckSigner(pub.hubConf, pub.username, key); err != nil {
+		return nil, err
+	}
+	return pub, nil
+}
 
-	fmt.Printf("\nView cast: https://farcaster.xyz/%s/0x%s\n", username, castHash)
+// channelUrl returns the parent URL of a channel, or "" if channel is empty.
+func (p *publisher) channelUrl(channel string) (string, error) {
+	if channel == "" {
+		return "", nil
+	}
+	if u, ok := p.channels[channel]; ok {
+		return u, nil
+	}
+	api := config.GetString("farcaster.api")
+	if api == "" {
+		api = fcclient.DefaultSignerApi
+	}
+	u, err := fcclient.ChannelUrl(api, channel)
+	if err != nil {
+		return "", err
+	}
+	p.channels[channel] = u
+	return u, nil
+}
 
+// cast waits until the metadata of up is available, and posts a cast for it.
+func (p *publisher) cast(text string, up uploadResult, parentUrl string) (string, error) {
+	if err := WaitForCID(up.Metadata, 5, 10); err != nil {
+		return "", err
+	}
+	castHash, err := fcclient.Cast(p.hubConf, p.username, p.key, text, up.Metadata, up.Filename, p.previews, parentUrl)
+	if err != nil {
+		return "", err
+	}
+	output.Status("cast", output.Fields{"hash": "0x" + castHash}, "[^] Cast posted: @%s/0x%s", p.username, castHash)
+	updatePins(func(r *pins.Registry) {
+		r.Published(up.Metadata, fmt.Sprintf("@%s/0x%s", p.username, castHash), p.fid)
+	})
+	return castHash, nil
 }
 
 func init() {
@@ -166,8 +511,20 @@ func init() {
 	uploadCmd.Flags().String("name", "", "Filename (override original filename)")
 	uploadCmd.Flags().String("mime", "", "mime/type (override automatic mime/type detection)")
 	uploadCmd.Flags().String("description", "", "Description. @file will read the text from file, @- will read the text from stdin.")
-	uploadCmd.Flags().String("artwork", "", "Path to artwork image.")
+	uploadCmd.Flags().String("artwork", "", "Path to artwork image. If not set, the cover art of the file or a generated placeholder is used.")
+	uploadCmd.Flags().Bool("no-artwork", false, "Do not generate placeholder artwork when the file has none")
+	uploadCmd.Flags().Bool("raw-artwork", false, "Upload the artwork unchanged, without resizing it, removing its metadata or creating a thumbnail")
 	uploadCmd.Flags().String("cast", "Uploaded with lemon3", "Cast text")
+	uploadCmd.Flags().String("channel", "", "Post the cast in this channel (channel id, or parent URL)")
+	uploadCmd.Flags().String("at", "", "Upload now, and post the cast at this time, for example 2026-11-01T09:00Z or \"2026-11-01 09:00\" (local time)")
+	uploadCmd.Flags().String("manifest", "", "Upload the files listed in a YAML manifest")
+	uploadCmd.Flags().StringSlice("preview", nil, "Preview URL template, {cid}, {filename} and {fid} are expanded (overrides preview.urls)")
+	uploadCmd.Flags().Bool("no-preview", false, "Do not add a preview URL to the cast")
+	uploadCmd.Flags().StringSlice("remote-pin", nil, "Also pin on these remote pinning services (default: all the pinning.<name> services of the profile)")
+	uploadCmd.Flags().Bool("no-remote-pin", false, "Do not pin on remote pinning services")
+	uploadCmd.Flags().Duration("remote-pin-timeout", 30*time.Minute, "How long to wait for remote pinning services to pin the file")
+	uploadCmd.Flags().Bool("dry-run", false, "Compute the CIDs and sign the cast, but do not upload, pin or cast anything")
+	uploadCmd.Flags().Bool("no-extract", false, "Do not read the title, description, artwork and duration from the file")
 }
 
 func detectMimeType(path string) (string, error) {
@@ -184,11 +541,57 @@ func detectMimeType(path string) (string, error) {
 		return "", err
 	}
 
-	// Detect content type
+	// Detect content type, and fall back to the file extension for
+	// formats http.DetectContentType does not know.
 	contentType := http.DetectContentType(buffer[:n])
+	if contentType == "application/octet-stream" || strings.HasPrefix(contentType, "text/plain") {
+		if t := mime.TypeByExtension(filepath.Ext(path)); t != "" {
+			return t, nil
+		}
+	}
 	return contentType, nil
 }
 
+// addAndPin uploads the file at path to IPFS and pins it.
+func addAndPin(path string, role string) (string, error) {
+	cid, err := ipfsclient.AddFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload %s: %w", path, err)
+	}
+	if err := ipfsclient.PinCID(cid); err != nil {
+		return "", fmt.Errorf("failed to pin %s: %w", role, err)
+	}
+	output.Status("pinned", output.Fields{"cid": cid, "role": role}, "[+] %s pinned.", cid)
+	return cid, nil
+}
+
+// hashOnly returns the CID of the file at path, without uploading it.
+func hashOnly(path string, role string) (string, error) {
+	cid, err := ipfsclient.HashFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", role, err)
+	}
+	return cid, nil
+}
+
+// writeTempArtwork saves extracted or generated artwork, so that it can be uploaded.
+func writeTempArtwork(data []byte) (string, error) {
+	ext := ".jpg"
+	if http.DetectContentType(data) == "image/png" {
+		ext = ".png"
+	}
+	f, err := os.CreateTemp("", "lemon3-artwork-*"+ext)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
 func getFileSize(filePath string) (int64, error) {
 	info, err := os.Stat(filePath)
 	if err != nil {
@@ -203,22 +606,28 @@ func WaitForCID(cid string, interval int, attempts int) error {
 	for attempt := 1; attempt <= attempts; attempt++ {
 		// Show spinner animation for ~5 seconds
 		for i := 0; i < 20; i++ {
-			fmt.Printf("\r[%c] Checking for %s on ipfs.io (attempt %d/10)", spinner[(attempt*20+i)%len(spinner)], cid, attempt)
+			output.Progress(
+				output.Fields{"event": "wait", "cid": cid, "attempt": attempt, "attempts": attempts},
+				"[%c] Checking for %s on ipfs.io (attempt %d/%d)", spinner[(attempt*20+i)%len(spinner)], cid, attempt, attempts,
+			)
 			time.Sleep(250 * time.Millisecond)
 		}
 
 		// Perform HEAD request
 		resp, err := http.Head(url)
 		if err != nil {
-			fmt.Printf("\r[!] Attempt %d failed: %v\n", attempt, err)
+			output.Status("wait", output.Fields{"cid": cid, "attempt": attempt, "error": err.Error()},
+				"\r[!] Attempt %d failed: %v", attempt, err)
 		} else {
 			resp.Body.Close()
 			if resp.StatusCode == http.StatusOK {
-				fmt.Printf("\r[✓] CID %s is now available on ipfs.io            \n", cid)
+				output.Status("available", output.Fields{"cid": cid},
+					"\r[✓] CID %s is now available on ipfs.io            ", cid)
 				return nil
 			}
 		}
 	}
-	fmt.Printf("\r[×] CID not available on ipfs.io                                                \n")
-	return fmt.Errorf("CID %s not available after %d attempts", cid, attempts)
+	output.Status("unavailable", output.Fields{"cid": cid},
+		"\r[×] CID not available on ipfs.io                                                ")
+	return errs.New(errs.Network, "CID %s not available after %d attempts", cid, attempts)
 }
@@ -6,24 +6,34 @@ import (
 	"strings"
 
 	"github.com/spf13/viper"
+	"github.com/vrypan/lemon3/errs"
 )
 
 // Initialize configuration using Viper
 func Load() string { // Load config and return config file path
-	configDir, _ := ConfigDir()
+	configDir, err := ProfileDir()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(errs.ExitCode(errs.Wrap(errs.Config, err)))
+	}
 
 	viper.SetEnvPrefix("LEMON3") // LEMON3_ env vars cna override config.
 	// For example, you can set LEMON3_HUB_HOST
 	viper.AutomaticEnv()
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-	viper.SetConfigFile(fmt.Sprintf("%s%c%s", configDir, os.PathSeparator, "config.yaml"))
+	viper.SetDefault("preview.urls", []string{"https://lemon3.vrypan.workers.dev/{cid}"})
+	viper.SetDefault("artwork.size", 1024)
+	viper.SetDefault("artwork.thumbnail_size", 256)
+	viper.SetConfigFile(fmt.Sprintf("%s%c%s", configDir, os.PathSeparator, configFileName))
 	viper.ReadInConfig()
 	return viper.ConfigFileUsed()
 }
 
 var (
-	GetString = viper.GetString
-	GetInt    = viper.GetInt
-	GetBool   = viper.GetBool
-	BindPFlag = viper.BindPFlag
+	GetString      = viper.GetString
+	GetStringSlice = viper.GetStringSlice
+	GetStringMap   = viper.GetStringMap
+	GetInt         = viper.GetInt
+	GetBool        = viper.GetBool
+	BindPFlag      = viper.BindPFlag
 )
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/vrypan/lemon3/errs"
+)
+
+// DefaultProfile uses the configuration files at the top of ConfigDir().
+const DefaultProfile = "default"
+
+const (
+	envProfile         = "LEMON3_PROFILE"
+	profilesDir        = "profiles"
+	currentProfileFile = "profile"
+	configFileName     = "config.yaml"
+)
+
+var profileName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Set by the --profile flag.
+var profileOverride string
+
+// SetProfile selects the profile used by Load, overriding LEMON3_PROFILE.
+func SetProfile(name string) {
+	profileOverride = name
+}
+
+/*
+ActiveProfile returns the profile in use: the one set with SetProfile
+(--profile), LEMON3_PROFILE, the one selected with "lemon3 config
+profiles use", or DefaultProfile.
+*/
+func ActiveProfile() string {
+	if profileOverride != "" {
+		return profileOverride
+	}
+	if p := os.Getenv(envProfile); p != "" {
+		return p
+	}
+	if dir, err := ConfigDir(); err == nil {
+		if b, err := os.ReadFile(filepath.Join(dir, currentProfileFile)); err == nil {
+			if p := strings.TrimSpace(string(b)); p != "" {
+				return p
+			}
+		}
+	}
+	return DefaultProfile
+}
+
+func ValidProfileName(name string) error {
+	if !profileName.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q, use letters, digits, - and _", name)
+	}
+	return nil
+}
+
+// profilePath returns the directory of a profile, without checking it exists.
+func profilePath(name string) (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	if name == DefaultProfile {
+		return dir, nil
+	}
+	if err := ValidProfileName(name); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, profilesDir, name), nil
+}
+
+/*
+ProfileDirOf returns the directory holding the files of a profile.
+The default profile uses ConfigDir(), other profiles use
+ConfigDir()/profiles/<name>, created by CreateProfileDir.
+*/
+func ProfileDirOf(name string) (string, error) {
+	p, err := profilePath(name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(p); os.IsNotExist(err) {
+		return "", errs.New(errs.NotFound, "profile %q does not exist, create it with \"lemon3 --profile %s setup\"", name, name)
+	}
+	return p, nil
+}
+
+// CreateProfileDir returns the directory of a profile, creating it if needed.
+func CreateProfileDir(name string) (string, error) {
+	p, err := profilePath(name)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(p, 0755); err != nil {
+		return "", fmt.Errorf("failed to create dir %s: %w", p, err)
+	}
+	return p, nil
+}
+
+// ProfileDir returns the directory of the active profile.
+func ProfileDir() (string, error) {
+	return ProfileDirOf(ActiveProfile())
+}
+
+// ProfileExists checks if the profile has a configuration file.
+func ProfileExists(name string) bool {
+	if name != DefaultProfile && ValidProfileName(name) != nil {
+		return false
+	}
+	dir, err := ConfigDir()
+	if err != nil {
+		return false
+	}
+	p := filepath.Join(dir, configFileName)
+	if name != DefaultProfile {
+		p = filepath.Join(dir, profilesDir, name, configFileName)
+	}
+	_, err = os.Stat(p)
+	return err == nil
+}
+
+// Profiles returns the names of all profiles with a configuration file.
+func Profiles() ([]string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	if ProfileExists(DefaultProfile) {
+		names = append(names, DefaultProfile)
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, profilesDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.IsDir() && ProfileExists(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	// The default profile comes first, the others by name.
+	sort.Slice(names, func(i, j int) bool {
+		if names[i] == DefaultProfile || names[j] == DefaultProfile {
+			return names[i] == DefaultProfile && names[j] != DefaultProfile
+		}
+		return names[i] < names[j]
+	})
+	return names, nil
+}
+
+// UseProfile makes name the profile used when neither --profile nor LEMON3_PROFILE are set.
+func UseProfile(name string) error {
+	if !ProfileExists(name) {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+	dir, err := ConfigDir()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, currentProfileFile), []byte(name+"\n"), 0644)
+}
+
+/*
+CopyProfile creates profile dst with the configuration of src.
+Other files, like the encrypted app key, are not copied.
+*/
+func CopyProfile(src, dst string) error {
+	if !ProfileExists(src) {
+		return fmt.Errorf("profile %q does not exist", src)
+	}
+	if ProfileExists(dst) {
+		return fmt.Errorf("profile %q already exists", dst)
+	}
+	srcDir, err := ProfileDirOf(src)
+	if err != nil {
+		return err
+	}
+	dstDir, err := CreateProfileDir(dst)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filepath.Join(srcDir, configFileName))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dstDir, configFileName), data, 0644)
+}
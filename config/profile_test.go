@@ -0,0 +1,142 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vrypan/lemon3/errs"
+)
+
+// tempConfigDir points ConfigDir to a new temp dir and returns it.
+func tempConfigDir(t *testing.T) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	t.Setenv(envXdgConfig, tmpDir)
+	dir := filepath.Join(tmpDir, lemon3Dir)
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetProfile("") })
+	return dir
+}
+
+func writeProfileConfig(t *testing.T, dir, name, data string) {
+	t.Helper()
+	p := filepath.Join(dir, configFileName)
+	if name != DefaultProfile {
+		p = filepath.Join(dir, profilesDir, name, configFileName)
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestActiveProfile(t *testing.T) {
+	tests := []struct {
+		name     string
+		override string
+		env      string
+		file     string
+		expected string
+	}{
+		{"nothing set", "", "", "", DefaultProfile},
+		{"profile file", "", "", "work\n", "work"},
+		{"blank profile file", "", "", " \n", DefaultProfile},
+		{"env over file", "", "test", "work\n", "test"},
+		{"override over env and file", "cli", "test", "work\n", "cli"},
+		{"override only", "cli", "", "", "cli"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := tempConfigDir(t)
+			t.Setenv(envProfile, tt.env)
+			SetProfile(tt.override)
+			if tt.file != "" {
+				if err := os.WriteFile(filepath.Join(dir, currentProfileFile), []byte(tt.file), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := ActiveProfile(); got != tt.expected {
+				t.Fatalf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestProfileDirOf(t *testing.T) {
+	dir := tempConfigDir(t)
+
+	p, err := ProfileDirOf(DefaultProfile)
+	if err != nil || p != dir {
+		t.Fatalf("expected %s, got %s (%v)", dir, p, err)
+	}
+
+	if _, err := ProfileDirOf("work"); errs.KindOf(err) != errs.NotFound {
+		t.Fatalf("expected a not found error for a missing profile, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, profilesDir, "work")); !os.IsNotExist(err) {
+		t.Fatal("ProfileDirOf created the profile dir")
+	}
+
+	expected := filepath.Join(dir, profilesDir, "work")
+	if p, err := CreateProfileDir("work"); err != nil || p != expected {
+		t.Fatalf("expected %s, got %s (%v)", expected, p, err)
+	}
+	if p, err := ProfileDirOf("work"); err != nil || p != expected {
+		t.Fatalf("expected %s, got %s (%v)", expected, p, err)
+	}
+
+	if _, err := ProfileDirOf("../work"); err == nil {
+		t.Fatal("expected an error for an invalid name")
+	}
+}
+
+func TestCopyProfile(t *testing.T) {
+	dir := tempConfigDir(t)
+	writeProfileConfig(t, dir, DefaultProfile, "ipfs:\n  api: http://localhost:5001\n")
+	if err := os.WriteFile(filepath.Join(dir, "app.key"), []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CopyProfile(DefaultProfile, "work"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, profilesDir, "work", configFileName))
+	if err != nil || string(data) != "ipfs:\n  api: http://localhost:5001\n" {
+		t.Fatalf("unexpected config %q (%v)", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, profilesDir, "work", "app.key")); !os.IsNotExist(err) {
+		t.Fatal("only the configuration should be copied")
+	}
+
+	if err := CopyProfile(DefaultProfile, "work"); err == nil {
+		t.Fatal("expected an error when the destination exists")
+	}
+	if err := CopyProfile("missing", "other"); err == nil {
+		t.Fatal("expected an error when the source does not exist")
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	dir := tempConfigDir(t)
+	for _, name := range []string{"zeta", DefaultProfile, "alpha"} {
+		writeProfileConfig(t, dir, name, "{}\n")
+	}
+	names, err := Profiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{DefaultProfile, "alpha", "zeta"}
+	if len(names) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, names)
+		}
+	}
+}
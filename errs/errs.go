@@ -0,0 +1,95 @@
+/*
+Package errs classifies lemon3 errors, so that the CLI can exit
+with a different code for each kind of failure.
+*/
+package errs
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Kind int
+
+const (
+	Unknown      Kind = iota
+	UserInput         // bad arguments or flags
+	Config            // missing or invalid configuration
+	Network           // Farcaster or IPFS node unreachable, or failed
+	NotFound          // user, cast or CID does not exist
+	Verification      // content or metadata is not valid lemon3 data
+	Auth              // app key, passphrase or API key rejected
+)
+
+var kindNames = map[Kind]string{
+	Unknown:      "unknown",
+	UserInput:    "user-input",
+	Config:       "config",
+	Network:      "network",
+	NotFound:     "not-found",
+	Verification: "verification",
+	Auth:         "auth",
+}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+/*
+ExitCode returns the process exit code for errors of this kind:
+1 unknown, 2 user input, 3 config, 4 network, 5 not found,
+6 verification, 7 auth.
+*/
+func (k Kind) ExitCode() int {
+	if k == Unknown {
+		return 1
+	}
+	return int(k) + 1
+}
+
+// Error is an error with a Kind.
+type Error struct {
+	Kind Kind
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New formats an error of the given kind, like fmt.Errorf.
+func New(kind Kind, format string, a ...any) error {
+	return &Error{Kind: kind, Err: fmt.Errorf(format, a...)}
+}
+
+// Wrap sets the kind of err, unless err already has one. Wrap(kind, nil) is nil.
+func Wrap(kind Kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	if KindOf(err) != Unknown {
+		return err
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+// KindOf returns the kind of the first Error in err's chain.
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return Unknown
+}
+
+// ExitCode returns the exit code for err, 0 if err is nil.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	return KindOf(err).ExitCode()
+}
@@ -0,0 +1,32 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected int
+	}{
+		{nil, 0},
+		{errors.New("plain"), 1},
+		{New(UserInput, "bad flag"), 2},
+		{New(Auth, "rejected"), 7},
+		{fmt.Errorf("upload: %w", New(Network, "timeout")), 4},
+		{Wrap(Config, New(NotFound, "no user")), 5},
+	}
+	for _, test := range tests {
+		if got := ExitCode(test.err); got != test.expected {
+			t.Errorf("ExitCode(%v) = %d, expected %d", test.err, got, test.expected)
+		}
+	}
+}
+
+func TestWrapNil(t *testing.T) {
+	if Wrap(Network, nil) != nil {
+		t.Fatal("Wrap(kind, nil) should be nil")
+	}
+}
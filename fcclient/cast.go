@@ -4,31 +4,61 @@ import (
 	"crypto/ed25519"
 	"encoding/hex"
 	"fmt"
-	"log"
+	"strings"
 	"time"
 
 	pb "github.com/vrypan/farcaster-go/farcaster"
+	"github.com/vrypan/lemon3/appkey"
+	"github.com/vrypan/lemon3/errs"
 	"github.com/zeebo/blake3"
 	"google.golang.org/protobuf/proto"
 )
 
-func Cast(hubConf HubConfig, username string, key string, text string, enclosureCid string) string {
-	var err error
-	var privateKey []byte
-
-	if privateKey, err = hex.DecodeString(key[2:]); err != nil {
-		log.Fatalf("Private key error: %v\n", err)
+/*
+Cast posts a cast with the lemon3 embeds for enclosureCid.
+Every URL in previewTemplates is expanded with PreviewUrls and added
+as an embed before the lemon3+ipfs:// link. If parentUrl is set, the cast
+is posted in that channel (see ChannelUrl).
+*/
+func Cast(hubConf HubConfig, username string, key appkey.Source, text string, enclosureCid string, filename string, previewTemplates []string, parentUrl string) (string, error) {
+	hub, err := NewFarcasterHub(hubConf)
+	if err != nil {
+		return "", err
 	}
+	defer hub.Close()
 
-	expandedKey := ed25519.NewKeyFromSeed(privateKey)
-	publicKey := expandedKey.Public().(ed25519.PublicKey)
+	message, err := castMessage(hub, username, key, text, enclosureCid, filename, previewTemplates, parentUrl)
+	if err != nil {
+		return "", err
+	}
+	msg, err := hub.SubmitMessage(message)
+	if err != nil {
+		return "", fmt.Errorf("error submitting message: %w", err)
+	}
+	return hex.EncodeToString(msg.Hash), nil
+}
 
-	hub := NewFarcasterHub(hubConf)
+// CastMessage returns the signed message Cast would submit, without submitting it.
+func CastMessage(hubConf HubConfig, username string, key appkey.Source, text string, enclosureCid string, filename string, previewTemplates []string, parentUrl string) (*pb.Message, error) {
+	hub, err := NewFarcasterHub(hubConf)
+	if err != nil {
+		return nil, err
+	}
 	defer hub.Close()
+	return castMessage(hub, username, key, text, enclosureCid, filename, previewTemplates, parentUrl)
+}
+
+func castMessage(hub *FarcasterHub, username string, key appkey.Source, text string, enclosureCid string, filename string, previewTemplates []string, parentUrl string) (*pb.Message, error) {
+	expandedKey, err := key.Load()
+	if err != nil {
+		return nil, errs.Wrap(errs.Auth, fmt.Errorf("private key error: %w", err))
+	}
+	privateKey := expandedKey.Seed()
+	publicKey := expandedKey.Public().(ed25519.PublicKey)
 
 	fid, err := hub.GetFidByUsername(username)
 	if err != nil {
-		log.Fatalf("Unable to get FID for %s: %v\n", username, err)
+		return nil, fmt.Errorf("unable to get FID for %s: %w", username, err)
 	}
 	var castType pb.CastType
 	if len(text) <= 320 {
@@ -37,9 +67,11 @@ func Cast(hubConf HubConfig, username string, key string, text string, enclosure
 		castType = pb.CastType(1)
 	}
 	embeds := []*pb.Embed{}
-	embeds = append(embeds, &pb.Embed{
-		Embed: &pb.Embed_Url{Url: "https://lemon3.vrypan.workers.dev/" + enclosureCid},
-	})
+	for _, u := range PreviewUrls(previewTemplates, enclosureCid, filename, fid) {
+		embeds = append(embeds, &pb.Embed{
+			Embed: &pb.Embed_Url{Url: u},
+		})
+	}
 	embeds = append(embeds, &pb.Embed{
 		Embed: &pb.Embed_Url{Url: "lemon3+ipfs://" + enclosureCid},
 	})
@@ -51,6 +83,9 @@ func Cast(hubConf HubConfig, username string, key string, text string, enclosure
 		Type:              castType,
 		Embeds:            embeds,
 	}
+	if parentUrl != "" {
+		messageBody.Parent = &pb.CastAddBody_ParentUrl{ParentUrl: parentUrl}
+	}
 
 	messageData := &pb.MessageData{
 		Type:      pb.MessageType(pb.MessageType_value["MESSAGE_TYPE_CAST_ADD"]),
@@ -61,12 +96,40 @@ func Cast(hubConf HubConfig, username string, key string, text string, enclosure
 			CastAddBody: messageBody,
 		},
 	}
-	message := CreateMessage(messageData, privateKey, publicKey)
+	return CreateMessage(messageData, privateKey, publicKey), nil
+}
+
+// RemoveCast removes the cast of username with hash, and returns the hash of the remove message.
+func RemoveCast(hubConf HubConfig, username string, key appkey.Source, hash []byte) (string, error) {
+	expandedKey, err := key.Load()
+	if err != nil {
+		return "", errs.Wrap(errs.Auth, fmt.Errorf("private key error: %w", err))
+	}
+	hub, err := NewFarcasterHub(hubConf)
+	if err != nil {
+		return "", err
+	}
+	defer hub.Close()
+
+	fid, err := hub.GetFidByUsername(username)
+	if err != nil {
+		return "", fmt.Errorf("unable to get FID for %s: %w", username, err)
+	}
+	messageData := &pb.MessageData{
+		Type:      pb.MessageType(pb.MessageType_value["MESSAGE_TYPE_CAST_REMOVE"]),
+		Fid:       fid,
+		Timestamp: uint32(time.Now().Unix() - FARCASTER_EPOCH),
+		Network:   pb.FarcasterNetwork(pb.FarcasterNetwork_value["FARCASTER_NETWORK_MAINNET"]),
+		Body: &pb.MessageData_CastRemoveBody{
+			CastRemoveBody: &pb.CastRemoveBody{TargetHash: hash},
+		},
+	}
+	message := CreateMessage(messageData, expandedKey.Seed(), expandedKey.Public().(ed25519.PublicKey))
 	msg, err := hub.SubmitMessage(message)
 	if err != nil {
-		log.Fatalf("Error submitting message: %v", err)
+		return "", fmt.Errorf("error submitting message: %w", err)
 	}
-	return hex.EncodeToString(msg.Hash)
+	return hex.EncodeToString(msg.Hash), nil
 }
 
 func CreateMessage(messageData *pb.MessageData, signerPrivate []byte, signerPublic []byte) *pb.Message {
@@ -95,19 +158,19 @@ func CreateMessage(messageData *pb.MessageData, signerPrivate []byte, signerPubl
 func CastGetEmbedUrls(username string, hash string) ([]string, error) {
 	var err error
 	if !IsInitialized() {
-		panic("fcclient.CastGetEmbeds called but hubInstance is not initialized.")
+		return nil, errs.New(errs.Config, "farcaster hub client is not initialized")
 	}
 	fid, err := hubInstance.GetFidByUsername(username)
 	if err != nil {
-		return nil, fmt.Errorf("Unable to get FID for %s: %v\n", username, err)
+		return nil, fmt.Errorf("unable to get FID for %s: %w", username, err)
 	}
-	hashBytes, err := hex.DecodeString(hash[2:])
-	if err != nil {
-		return nil, fmt.Errorf("Error parsing hash %s: %v\n", hash, err)
+	hashBytes, err := hex.DecodeString(strings.TrimPrefix(hash, "0x"))
+	if err != nil || len(hashBytes) != 20 {
+		return nil, errs.New(errs.UserInput, "invalid cast hash %s, use the full 0x-prefixed hash", hash)
 	}
 	cast, err := hubInstance.GetCast(fid, hashBytes)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to get cast: %v\n", err)
+		return nil, fmt.Errorf("failed to get cast: %w", err)
 	}
 
 	embeds := cast.Data.GetCastAddBody().Embeds
@@ -124,16 +187,16 @@ func CastGetEmbedUrls(username string, hash string) ([]string, error) {
 func GetCastsByFname(username string, pageSize uint32, reverse bool) ([]*pb.Message, error) {
 	var err error
 	if !IsInitialized() {
-		panic("fcclient.CastGetEmbeds called but hubInstance is not initialized.")
+		return nil, errs.New(errs.Config, "farcaster hub client is not initialized")
 	}
 	fid, err := hubInstance.GetFidByUsername(username)
 	if err != nil {
-		return nil, fmt.Errorf("Unable to get FID for %s: %v\n", username, err)
+		return nil, fmt.Errorf("unable to get FID for %s: %w", username, err)
 	}
 	msg, err := hubInstance.GetCastsByFid(fid, pageSize, reverse)
 
 	if err != nil {
-		return nil, fmt.Errorf("Failed to get casts for %s: %v\n", username, err)
+		return nil, fmt.Errorf("failed to get casts for %s: %w", username, err)
 	}
 	return msg.Messages, nil
 }
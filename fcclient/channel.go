@@ -0,0 +1,48 @@
+package fcclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/vrypan/lemon3/errs"
+)
+
+/*
+ChannelUrl returns the parent URL casts in channel are posted under.
+channel is a channel id (music, or /music), which is looked up with the
+Farcaster client API, or a parent URL, which is returned as is.
+*/
+func ChannelUrl(api string, channel string) (string, error) {
+	if strings.Contains(channel, "://") {
+		return channel, nil
+	}
+	id := strings.TrimPrefix(channel, "/")
+	u := strings.TrimSuffix(api, "/") + "/v1/channel?channelId=" + url.QueryEscape(id)
+	resp, err := http.Get(u)
+	if err != nil {
+		return "", errs.Wrap(errs.Network, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", errs.New(errs.NotFound, "channel /%s not found", id)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", errs.New(errs.Network, "channel lookup failed: %s", resp.Status)
+	}
+	var result struct {
+		Result struct {
+			Channel struct {
+				Url string `json:"url"`
+			} `json:"channel"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if result.Result.Channel.Url == "" {
+		return "", errs.New(errs.NotFound, "channel /%s not found", id)
+	}
+	return result.Result.Channel.Url, nil
+}
@@ -3,12 +3,12 @@ package fcclient
 import (
 	"context"
 	"fmt"
-	"log"
 
-	//"time"
 	"crypto/ed25519"
+	"time"
 
 	pb "github.com/vrypan/farcaster-go/farcaster"
+	"github.com/vrypan/lemon3/errs"
 	"github.com/zeebo/blake3"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
@@ -33,8 +33,13 @@ type FarcasterHub struct {
 	ctx_cancel context.CancelFunc
 }
 
-func Init(conf HubConfig) {
-	hubInstance = NewFarcasterHub(conf)
+func Init(conf HubConfig) error {
+	hub, err := NewFarcasterHub(conf)
+	if err != nil {
+		return err
+	}
+	hubInstance = hub
+	return nil
 }
 
 func IsInitialized() bool {
@@ -58,7 +63,7 @@ func apiKeyInterceptor(header, value string) grpc.UnaryClientInterceptor {
 	}
 }
 
-func NewFarcasterHub(conf HubConfig) *FarcasterHub {
+func NewFarcasterHub(conf HubConfig) (*FarcasterHub, error) {
 
 	cred := insecure.NewCredentials()
 
@@ -78,11 +83,7 @@ func NewFarcasterHub(conf HubConfig) *FarcasterHub {
 		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(20*1024*1024)),
 	)
 	if err != nil {
-		log.Fatalf("Failed to connect: %v", err)
-	}
-
-	if err != nil {
-		log.Fatalf("Did not connect: %v", err)
+		return nil, errs.New(errs.Network, "failed to connect to %s: %w", conf.Host, err)
 	}
 	client := pb.NewHubServiceClient(conn)
 
@@ -92,7 +93,7 @@ func NewFarcasterHub(conf HubConfig) *FarcasterHub {
 		client:     client,
 		ctx:        ctx,
 		ctx_cancel: cancel,
-	}
+	}, nil
 }
 
 func (h FarcasterHub) Close() {
@@ -100,10 +101,27 @@ func (h FarcasterHub) Close() {
 	h.ctx_cancel()
 }
 
+// GetInfo returns the node version and stats. It fails after 10s if the node is unreachable.
+func (hub FarcasterHub) GetInfo() (*pb.GetInfoResponse, error) {
+	ctx, cancel := context.WithTimeout(hub.ctx, 10*time.Second)
+	defer cancel()
+	info, err := hub.client.GetInfo(ctx, &pb.GetInfoRequest{})
+	return info, hubError(err)
+}
+
 func (hub FarcasterHub) GetCastsByFid(fid uint64, pageSize uint32, reverse bool) (*pb.MessagesResponse, error) {
 	msg, err := hub.client.GetCastsByFid(hub.ctx, &pb.FidRequest{Fid: fid, Reverse: &reverse, PageSize: &pageSize})
 	if err != nil {
-		return nil, err
+		return nil, hubError(err)
+	}
+	return msg, nil
+}
+
+// GetCastsByFidPage returns the page of casts that starts at pageToken (nil for the first page).
+func (hub FarcasterHub) GetCastsByFidPage(fid uint64, pageSize uint32, reverse bool, pageToken []byte) (*pb.MessagesResponse, error) {
+	msg, err := hub.client.GetCastsByFid(hub.ctx, &pb.FidRequest{Fid: fid, Reverse: &reverse, PageSize: &pageSize, PageToken: pageToken})
+	if err != nil {
+		return nil, hubError(err)
 	}
 	return msg, nil
 }
@@ -136,7 +154,7 @@ func (hub FarcasterHub) SubmitMessageData(messageData *pb.MessageData, signerPri
 
 func (hub FarcasterHub) SubmitMessage(message *pb.Message) (*pb.Message, error) {
 	msg, err := hub.client.SubmitMessage(hub.ctx, message)
-	return msg, err
+	return msg, hubError(err)
 }
 
 func (hub FarcasterHub) GetUserData(fid uint64, user_data_type string) (*pb.Message, error) {
@@ -170,7 +188,7 @@ func (hub FarcasterHub) GetUsernameProofsByFid(fid uint64) ([]string, error) {
 func (hub FarcasterHub) GetFidByUsername(username string) (uint64, error) {
 	message, err := hub.client.GetUsernameProof(hub.ctx, &pb.UsernameProofRequest{Name: []byte(username)})
 	if err != nil {
-		return 0, fmt.Errorf("failed to get username proof: %w", err)
+		return 0, hubError(fmt.Errorf("failed to get username proof: %w", err))
 	}
 	return message.Fid, nil
 }
@@ -188,7 +206,8 @@ func (hub FarcasterHub) GetReactionsByFid(fid uint64, reaction string, pageSize
 }
 
 func (hub FarcasterHub) GetCast(fid uint64, hash []byte) (*pb.Message, error) {
-	return hub.client.GetCast(hub.ctx, &pb.CastId{Fid: fid, Hash: hash})
+	msg, err := hub.client.GetCast(hub.ctx, &pb.CastId{Fid: fid, Hash: hash})
+	return msg, hubError(err)
 }
 
 func (hub FarcasterHub) GetCastReplies(fid uint64, hash []byte) (*pb.MessagesResponse, error) {
@@ -0,0 +1,24 @@
+package fcclient
+
+import (
+	"github.com/vrypan/lemon3/errs"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// hubError sets the errs.Kind of an error returned by the hub, based on its gRPC status code.
+func hubError(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch status.Code(err) {
+	case codes.NotFound:
+		return errs.Wrap(errs.NotFound, err)
+	case codes.Unauthenticated, codes.PermissionDenied:
+		return errs.Wrap(errs.Auth, err)
+	case codes.InvalidArgument:
+		return errs.Wrap(errs.UserInput, err)
+	default:
+		return errs.Wrap(errs.Network, err)
+	}
+}
@@ -0,0 +1,46 @@
+package fcclient
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// MaxEmbeds is the number of embeds a hub accepts in a single cast.
+// One of them is always the lemon3+ipfs:// link.
+const MaxEmbeds = 2
+
+// MaxPreviewUrls is the number of preview URLs that fit in a cast, next to the lemon3+ipfs:// link.
+const MaxPreviewUrls = MaxEmbeds - 1
+
+/*
+PreviewUrls expands the preview URL templates used as cast embeds.
+The placeholders {cid}, {filename} and {fid} are replaced by the
+metadata CID, the (escaped) filename and the FID of the author.
+Empty templates are skipped.
+*/
+func PreviewUrls(templates []string, cid string, filename string, fid uint64) []string {
+	r := strings.NewReplacer(
+		"{cid}", cid,
+		"{filename}", url.PathEscape(filename),
+		"{fid}", strconv.FormatUint(fid, 10),
+	)
+	urls := []string{}
+	for _, t := range templates {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		urls = append(urls, r.Replace(t))
+	}
+	return urls
+}
+
+// CheckPreviewUrls checks that templates fit in a cast.
+func CheckPreviewUrls(templates []string) error {
+	if n := len(PreviewUrls(templates, "", "", 0)); n > MaxPreviewUrls {
+		return fmt.Errorf("a cast can have %d preview URL, got %d", MaxPreviewUrls, n)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package fcclient
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPreviewUrls(t *testing.T) {
+	got := PreviewUrls(
+		[]string{"https://example.com/{cid}", "", "https://example.com/{fid}/{filename}"},
+		"bafyrei", "my file.mp3", 280,
+	)
+	expected := []string{"https://example.com/bafyrei", "https://example.com/280/my%20file.mp3"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	if got := PreviewUrls(nil, "bafyrei", "", 0); len(got) != 0 {
+		t.Fatalf("expected no urls, got %v", got)
+	}
+}
+
+func TestCheckPreviewUrls(t *testing.T) {
+	if err := CheckPreviewUrls([]string{"https://example.com/{cid}", " "}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := CheckPreviewUrls(nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := CheckPreviewUrls([]string{"https://a.example/{cid}", "https://b.example/{cid}"}); err == nil {
+		t.Error("expected error for two preview URLs")
+	}
+}
@@ -0,0 +1,193 @@
+package fcclient
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	pb "github.com/vrypan/farcaster-go/farcaster"
+	"github.com/vrypan/lemon3/errs"
+)
+
+// Contract verifying signed key requests (SignedKeyRequestValidator on OP mainnet).
+const SIGNED_KEY_REQUEST_VALIDATOR = "0x00000000FC700472606ED4fA22623Acf62c60553"
+
+// DefaultSignerApi is the Farcaster client API used to create signed key request deeplinks.
+const DefaultSignerApi = "https://api.farcaster.xyz"
+
+/*
+GetActiveSigners returns the public keys of the active (added and not
+removed) ed25519 signers of fid.
+*/
+func (hub FarcasterHub) GetActiveSigners(fid uint64) ([][]byte, error) {
+	keys := [][]byte{}
+	var pageToken []byte
+	for {
+		resp, err := hub.client.GetOnChainSignersByFid(hub.ctx, &pb.FidRequest{Fid: fid, PageToken: pageToken})
+		if err != nil {
+			return nil, hubError(err)
+		}
+		for _, e := range resp.Events {
+			body := e.GetSignerEventBody()
+			if body == nil || body.KeyType != 1 {
+				continue
+			}
+			if body.EventType == pb.SignerEventType_SIGNER_EVENT_TYPE_ADD {
+				keys = append(keys, body.Key)
+			}
+		}
+		if len(resp.NextPageToken) == 0 {
+			break
+		}
+		pageToken = resp.NextPageToken
+	}
+	return keys, nil
+}
+
+// IsActiveSigner checks if key is an active signer of fid.
+func (hub FarcasterHub) IsActiveSigner(fid uint64, key []byte) (bool, error) {
+	keys, err := hub.GetActiveSigners(fid)
+	if err != nil {
+		return false, err
+	}
+	for _, k := range keys {
+		if bytes.Equal(k, key) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+/*
+CheckSigner verifies that key is an active signer of username's FID,
+and returns the FID.
+*/
+func CheckSigner(hubConf HubConfig, username string, key ed25519.PrivateKey) (uint64, error) {
+	hub, err := NewFarcasterHub(hubConf)
+	if err != nil {
+		return 0, err
+	}
+	defer hub.Close()
+
+	fid, err := hub.GetFidByUsername(username)
+	if err != nil {
+		return 0, fmt.Errorf("unable to get FID for %s: %w", username, err)
+	}
+	public := key.Public().(ed25519.PublicKey)
+	active, err := hub.IsActiveSigner(fid, public)
+	if err != nil {
+		return fid, fmt.Errorf("unable to get signers of FID %d: %w", fid, err)
+	}
+	if !active {
+		return fid, errs.New(errs.Auth, "app key 0x%x is not an active signer of @%s (FID %d)", []byte(public), username, fid)
+	}
+	return fid, nil
+}
+
+/*
+SignedKeyRequestTypedData returns the EIP-712 typed data that the
+custody address of requestFid must sign to approve key. The result
+can be passed to eth_signTypedData_v4 or "cast wallet sign --data".
+*/
+func SignedKeyRequestTypedData(requestFid uint64, key []byte, deadline int64) map[string]any {
+	return map[string]any{
+		"domain": map[string]any{
+			"name":              "Farcaster SignedKeyRequestValidator",
+			"version":           "1",
+			"chainId":           10,
+			"verifyingContract": SIGNED_KEY_REQUEST_VALIDATOR,
+		},
+		"types": map[string]any{
+			"EIP712Domain": []map[string]string{
+				{"name": "name", "type": "string"},
+				{"name": "version", "type": "string"},
+				{"name": "chainId", "type": "uint256"},
+				{"name": "verifyingContract", "type": "address"},
+			},
+			"SignedKeyRequest": []map[string]string{
+				{"name": "requestFid", "type": "uint256"},
+				{"name": "key", "type": "bytes"},
+				{"name": "deadline", "type": "uint256"},
+			},
+		},
+		"primaryType": "SignedKeyRequest",
+		"message": map[string]any{
+			"requestFid": requestFid,
+			"key":        "0x" + hex.EncodeToString(key),
+			"deadline":   deadline,
+		},
+	}
+}
+
+/*
+SignedKeyRequestMetadata ABI-encodes the SignedKeyRequestMetadata struct
+(requestFid, requestSigner, signature, deadline) that is passed as
+metadata to KeyGateway.add() together with the key.
+*/
+func SignedKeyRequestMetadata(requestFid uint64, requestSigner []byte, signature []byte, deadline int64) ([]byte, error) {
+	if len(requestSigner) != 20 {
+		return nil, fmt.Errorf("request signer must be a 20-byte address, got %d bytes", len(requestSigner))
+	}
+	word := func(v uint64) []byte {
+		w := make([]byte, 32)
+		binary.BigEndian.PutUint64(w[24:], v)
+		return w
+	}
+	var buf bytes.Buffer
+	buf.Write(word(0x20)) // offset of the (dynamic) tuple
+	buf.Write(word(requestFid))
+	buf.Write(append(make([]byte, 12), requestSigner...))
+	buf.Write(word(0x80)) // offset of signature, relative to the tuple
+	buf.Write(word(uint64(deadline)))
+	buf.Write(word(uint64(len(signature))))
+	buf.Write(signature)
+	if pad := len(signature) % 32; pad != 0 {
+		buf.Write(make([]byte, 32-pad))
+	}
+	return buf.Bytes(), nil
+}
+
+/*
+SubmitSignedKeyRequest registers a signed key request with the Farcaster
+client API and returns the request token and the deeplink the user
+must open to approve the key.
+*/
+func SubmitSignedKeyRequest(api string, requestFid uint64, key []byte, signature []byte, deadline int64) (string, string, error) {
+	payload, err := json.Marshal(map[string]any{
+		"key":        "0x" + hex.EncodeToString(key),
+		"requestFid": requestFid,
+		"signature":  "0x" + hex.EncodeToString(signature),
+		"deadline":   deadline,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	url := strings.TrimSuffix(api, "/") + "/v2/signed-key-requests"
+	resp, err := http.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return "", "", errs.Wrap(errs.Network, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", "", errs.New(errs.Network, "signed key request failed: %s", string(body))
+	}
+	var result struct {
+		Result struct {
+			SignedKeyRequest struct {
+				Token       string `json:"token"`
+				DeeplinkUrl string `json:"deeplinkUrl"`
+			} `json:"signedKeyRequest"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", "", err
+	}
+	return result.Result.SignedKeyRequest.Token, result.Result.SignedKeyRequest.DeeplinkUrl, nil
+}
@@ -0,0 +1,39 @@
+package fcclient
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestSignedKeyRequestMetadata(t *testing.T) {
+	signer, _ := hex.DecodeString("a6a8736f18f383f1cc2d938576933e5ea7df01a1")
+	signature := bytes.Repeat([]byte{0xab}, 65)
+	metadata, err := SignedKeyRequestMetadata(9152, signer, signature, 1700000000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metadata) != 6*32+96 {
+		t.Fatalf("expected %d bytes, got %d", 6*32+96, len(metadata))
+	}
+	word := func(i int) string { return hex.EncodeToString(metadata[i*32 : (i+1)*32]) }
+	expected := []string{
+		"0000000000000000000000000000000000000000000000000000000000000020",
+		"00000000000000000000000000000000000000000000000000000000000023c0",
+		"000000000000000000000000a6a8736f18f383f1cc2d938576933e5ea7df01a1",
+		"0000000000000000000000000000000000000000000000000000000000000080",
+		"000000000000000000000000000000000000000000000000000000006553f100",
+		"0000000000000000000000000000000000000000000000000000000000000041",
+	}
+	for i, e := range expected {
+		if word(i) != e {
+			t.Fatalf("word %d: expected %s, got %s", i, e, word(i))
+		}
+	}
+	if !bytes.Equal(metadata[6*32:6*32+65], signature) || !bytes.Equal(metadata[6*32+65:], make([]byte, 31)) {
+		t.Fatal("signature is not encoded correctly")
+	}
+	if _, err := SignedKeyRequestMetadata(1, signer[:10], signature, 0); err == nil {
+		t.Fatal("expected error for short address")
+	}
+}
@@ -0,0 +1,245 @@
+/*
+Package id3 reads ID3v2.3/v2.4 tags, and writes ID3v2.4 tags to MP3 files.
+
+Frames of an existing ID3v2.3 or v2.4 tag are kept, unless the new tag
+replaces them. Files are rewritten through a temporary file, so an
+error never leaves a half-written file behind.
+*/
+package id3
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Tag holds the frames to write. Empty fields are not written.
+type Tag struct {
+	Title       string            // TIT2
+	Artist      string            // TPE1
+	Comment     string            // COMM
+	Date        time.Time         // TDRC
+	Length      time.Duration     // TLEN
+	Picture     []byte            // APIC, front cover
+	PictureMime string            // "image/jpeg" if empty
+	UserText    map[string]string // TXXX, description: value
+}
+
+type frame struct {
+	id   string
+	data []byte
+}
+
+const (
+	headerLen    = 10
+	encodingUTF8 = 3
+)
+
+// textFrame returns the body of a UTF-8 text frame.
+func textFrame(text string) []byte {
+	return append([]byte{encodingUTF8}, text...)
+}
+
+func (t Tag) frames() []frame {
+	frames := []frame{}
+	if t.Title != "" {
+		frames = append(frames, frame{"TIT2", textFrame(t.Title)})
+	}
+	if t.Artist != "" {
+		frames = append(frames, frame{"TPE1", textFrame(t.Artist)})
+	}
+	if !t.Date.IsZero() {
+		frames = append(frames, frame{"TDRC", textFrame(t.Date.UTC().Format("2006-01-02T15:04:05"))})
+	}
+	if t.Length > 0 {
+		frames = append(frames, frame{"TLEN", textFrame(strconv.FormatInt(t.Length.Milliseconds(), 10))})
+	}
+	if t.Comment != "" {
+		// encoding, language, empty short description, text
+		data := append([]byte{encodingUTF8}, "eng"...)
+		data = append(data, 0)
+		frames = append(frames, frame{"COMM", append(data, t.Comment...)})
+	}
+	if len(t.Picture) > 0 {
+		mime := t.PictureMime
+		if mime == "" {
+			mime = "image/jpeg"
+		}
+		// encoding, MIME type, picture type 3 (front cover), empty description, data
+		data := append([]byte{encodingUTF8}, mime...)
+		data = append(data, 0, 3, 0)
+		frames = append(frames, frame{"APIC", append(data, t.Picture...)})
+	}
+	for desc, value := range t.UserText {
+		data := append([]byte{encodingUTF8}, desc...)
+		data = append(data, 0)
+		frames = append(frames, frame{"TXXX", append(data, value...)})
+	}
+	return frames
+}
+
+// replaces reports if a frame of an existing tag must be dropped when t is written.
+func (t Tag) replaces(f frame) bool {
+	switch f.id {
+	case "TIT2":
+		return t.Title != ""
+	case "TPE1":
+		return t.Artist != ""
+	case "TDRC", "TYER", "TDAT", "TIME", "TRDA":
+		return !t.Date.IsZero()
+	case "TLEN":
+		return t.Length > 0
+	case "COMM":
+		return t.Comment != ""
+	case "APIC":
+		return len(t.Picture) > 0
+	case "TXXX":
+		desc, _, _ := bytes.Cut(f.data[1:], []byte{0})
+		_, ok := t.UserText[string(desc)]
+		return ok && f.data[0] != 1 && f.data[0] != 2 // UTF-16 descriptions are not compared
+	}
+	return false
+}
+
+func syncsafe(n int) []byte {
+	return []byte{byte(n>>21) & 0x7f, byte(n>>14) & 0x7f, byte(n>>7) & 0x7f, byte(n) & 0x7f}
+}
+
+func unsyncsafe(b []byte) int {
+	return int(b[0]&0x7f)<<21 | int(b[1]&0x7f)<<14 | int(b[2]&0x7f)<<7 | int(b[3]&0x7f)
+}
+
+// encode returns a complete ID3v2.4 tag with frames.
+func encode(frames []frame) []byte {
+	var body bytes.Buffer
+	for _, f := range frames {
+		body.WriteString(f.id)
+		body.Write(syncsafe(len(f.data)))
+		body.Write([]byte{0, 0})
+		body.Write(f.data)
+	}
+	header := append([]byte("ID3"), 4, 0, 0)
+	header = append(header, syncsafe(body.Len())...)
+	return append(header, body.Bytes()...)
+}
+
+// Bytes returns t as a complete ID3v2.4 tag.
+func (t Tag) Bytes() []byte {
+	return encode(t.frames())
+}
+
+/*
+readTag reads the ID3v2 tag at the start of r, if there is one, and
+returns its frames and the number of bytes it takes. Frames of tags it
+can not parse safely (v2.2, unsynchronised, compressed or encrypted) are
+dropped, but the tag is still skipped.
+*/
+func readTag(r io.Reader) ([]frame, int, error) {
+	header := make([]byte, headerLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, 0, nil // shorter than a header, no tag
+	}
+	if string(header[:3]) != "ID3" {
+		return nil, 0, nil
+	}
+	version, flags := header[3], header[5]
+	size := unsyncsafe(header[6:10])
+	tagLen := headerLen + size
+	if flags&0x10 != 0 {
+		tagLen += headerLen // footer
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, 0, fmt.Errorf("truncated ID3 tag: %w", err)
+	}
+	if (version != 3 && version != 4) || flags&0x80 != 0 {
+		return nil, tagLen, nil
+	}
+
+	if flags&0x40 != 0 && len(body) >= 4 { // extended header
+		n := int(binary.BigEndian.Uint32(body[:4])) + 4 // v2.3: size excludes itself
+		if version == 4 {
+			n = unsyncsafe(body[:4])
+		}
+		if n > len(body) {
+			return nil, tagLen, nil
+		}
+		body = body[n:]
+	}
+
+	frames := []frame{}
+	for len(body) >= headerLen && body[0] != 0 {
+		id := string(body[:4])
+		n := int(binary.BigEndian.Uint32(body[4:8]))
+		if version == 4 {
+			n = unsyncsafe(body[4:8])
+		}
+		if headerLen+n > len(body) {
+			break
+		}
+		formatFlags := body[9]
+		data := body[headerLen : headerLen+n]
+		body = body[headerLen+n:]
+		if (version == 3 && formatFlags&0xe0 != 0) || (version == 4 && formatFlags&0x4f != 0) {
+			continue // grouped, compressed, encrypted, unsynchronised or with a data length indicator
+		}
+		if len(data) > 0 {
+			frames = append(frames, frame{id, data})
+		}
+	}
+	return frames, tagLen, nil
+}
+
+// WriteFile writes tag to the MP3 file at path, replacing the frames it sets.
+func WriteFile(path string, tag Tag) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	old, tagLen, err := readTag(src)
+	if err != nil {
+		return err
+	}
+	if _, err := src.Seek(int64(tagLen), io.SeekStart); err != nil {
+		return err
+	}
+
+	frames := tag.frames()
+	for _, f := range old {
+		if !tag.replaces(f) {
+			frames = append(frames, f)
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // fails harmlessly after the rename
+	w := bufio.NewWriter(tmp)
+	if _, err := w.Write(encode(frames)); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := io.Copy(w, src); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := errors.Join(w.Flush(), tmp.Close()); err != nil {
+		return err
+	}
+	if info, err := src.Stat(); err == nil {
+		os.Chmod(tmp.Name(), info.Mode())
+	}
+	src.Close()
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,131 @@
+package id3
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func framesOf(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	frames, tagLen, err := readTag(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tagLen == 0 {
+		t.Fatal("no tag found")
+	}
+	m := map[string][]byte{}
+	for _, f := range frames {
+		m[f.id] = f.data
+	}
+	return m
+}
+
+func TestWriteFile(t *testing.T) {
+	audio := []byte{0xff, 0xfb, 0x90, 0x64, 1, 2, 3, 4}
+	existing := encode([]frame{
+		{"TIT2", textFrame("Old title")},
+		{"TALB", textFrame("Album")},
+		{"TXXX", append(textFrame("LEMON3_CID\x00"), "old"...)},
+	})
+	path := filepath.Join(t.TempDir(), "track.mp3")
+	if err := os.WriteFile(path, append(existing, audio...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tag := Tag{
+		Title:       "New title",
+		Artist:      "vrypan.eth",
+		Comment:     "A description",
+		Date:        time.Date(2025, 3, 2, 18, 11, 0, 0, time.UTC),
+		Picture:     []byte{0x89, 'P', 'N', 'G'},
+		PictureMime: "image/png",
+		UserText:    map[string]string{"LEMON3_CID": "bafycid"},
+	}
+	if err := WriteFile(path, tag); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasSuffix(data, audio) {
+		t.Fatal("audio data was not preserved")
+	}
+	if string(data[:5]) != "ID3\x04\x00" {
+		t.Fatalf("expected an ID3v2.4 header, got %q", data[:5])
+	}
+
+	frames := framesOf(t, data)
+	expected := map[string]string{
+		"TIT2": "\x03New title",
+		"TPE1": "\x03vrypan.eth",
+		"TALB": "\x03Album",
+		"TDRC": "\x032025-03-02T18:11:00",
+		"COMM": "\x03eng\x00A description",
+		"APIC": "\x03image/png\x00\x03\x00\x89PNG",
+		"TXXX": "\x03LEMON3_CID\x00bafycid",
+	}
+	for id, value := range expected {
+		if string(frames[id]) != value {
+			t.Errorf("%s = %q, expected %q", id, frames[id], value)
+		}
+	}
+	if len(frames) != len(expected) {
+		t.Errorf("expected %d frames, got %d", len(expected), len(frames))
+	}
+}
+
+func TestWriteFileWithoutTag(t *testing.T) {
+	audio := []byte{0xff, 0xfb, 0x90, 0x64}
+	path := filepath.Join(t.TempDir(), "track.mp3")
+	if err := os.WriteFile(path, audio, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(path, Tag{Title: "Title"}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if !bytes.HasSuffix(data, audio) {
+		t.Fatal("audio data was not preserved")
+	}
+	if string(framesOf(t, data)["TIT2"]) != "\x03Title" {
+		t.Fatal("title not written")
+	}
+}
+
+func TestSyncsafe(t *testing.T) {
+	for _, n := range []int{0, 127, 128, 255, 1 << 20, 1<<28 - 1} {
+		if got := unsyncsafe(syncsafe(n)); got != n {
+			t.Errorf("unsyncsafe(syncsafe(%d)) = %d", n, got)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	utf16Title := []byte{1, 0xff, 0xfe, 'H', 0, 'i', 0}
+	data := encode([]frame{
+		{"TIT2", utf16Title},
+		{"COMM", append([]byte{0}, "engiTunNORM\x00 0000"...)},
+		{"COMM", append([]byte{3}, "eng\x00Caf\xc3\xa9"...)},
+		{"TLEN", textFrame("61500")},
+		{"APIC", append([]byte{0}, "image/png\x00\x00\x00back"...)},
+		{"APIC", append([]byte{0}, "image/jpeg\x00\x03cover\x00front"...)},
+	})
+	tag, n, err := Read(bytes.NewReader(append(data, 0xff, 0xfb)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(data) {
+		t.Errorf("tag length = %d, expected %d", n, len(data))
+	}
+	if tag.Title != "Hi" || tag.Comment != "Café" || tag.Length != 61500*time.Millisecond {
+		t.Errorf("unexpected tag %+v", tag)
+	}
+	if string(tag.Picture) != "front" || tag.PictureMime != "image/jpeg" {
+		t.Errorf("expected the front cover, got %q (%s)", tag.Picture, tag.PictureMime)
+	}
+}
@@ -0,0 +1,133 @@
+package id3
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+/*
+Read reads the ID3v2 tag at the start of r. It returns an empty Tag if
+there is none, and the size of the tag in bytes, so that the caller can
+find the audio data after it.
+*/
+func Read(r io.Reader) (Tag, int, error) {
+	frames, tagLen, err := readTag(r)
+	if err != nil {
+		return Tag{}, 0, err
+	}
+	t := Tag{}
+	pictureType := -1
+	plainComment := false
+	for _, f := range frames {
+		enc := f.data[0]
+		body := f.data[1:]
+		switch f.id {
+		case "TIT2":
+			t.Title = decodeText(enc, body)
+		case "TPE1":
+			t.Artist = decodeText(enc, body)
+		case "TDRC", "TYER":
+			if t.Date.IsZero() {
+				t.Date = parseDate(decodeText(enc, body))
+			}
+		case "TLEN":
+			if ms, err := strconv.ParseInt(decodeText(enc, body), 10, 64); err == nil {
+				t.Length = time.Duration(ms) * time.Millisecond
+			}
+		case "COMM":
+			if len(body) < 3 {
+				continue
+			}
+			desc, text := splitText(enc, body[3:])
+			// Players store settings in comments with a description, like
+			// iTunNORM, so prefer the comment without one.
+			if decodeText(enc, desc) == "" && !plainComment {
+				t.Comment = decodeText(enc, text)
+				plainComment = true
+			} else if t.Comment == "" {
+				t.Comment = decodeText(enc, text)
+			}
+		case "APIC":
+			mime, rest, ok := bytes.Cut(body, []byte{0})
+			if !ok || len(rest) < 1 {
+				continue
+			}
+			kind := int(rest[0])
+			_, data := splitText(enc, rest[1:])
+			// Prefer the front cover (3), then the first picture.
+			if len(data) > 0 && (pictureType == -1 || (kind == 3 && pictureType != 3)) {
+				pictureType = kind
+				t.Picture = data
+				t.PictureMime = string(mime)
+			}
+		case "TXXX":
+			desc, value := splitText(enc, body)
+			if t.UserText == nil {
+				t.UserText = map[string]string{}
+			}
+			t.UserText[decodeText(enc, desc)] = decodeText(enc, value)
+		}
+	}
+	return t, tagLen, nil
+}
+
+// splitText splits b at the first string terminator of the encoding.
+func splitText(enc byte, b []byte) ([]byte, []byte) {
+	if enc == 1 || enc == 2 { // UTF-16, the terminator is two aligned zero bytes
+		for i := 0; i+1 < len(b); i += 2 {
+			if b[i] == 0 && b[i+1] == 0 {
+				return b[:i], b[i+2:]
+			}
+		}
+		return b, nil
+	}
+	first, rest, _ := bytes.Cut(b, []byte{0})
+	return first, rest
+}
+
+// decodeText decodes the text of a frame. Multiple values are joined with "/".
+func decodeText(enc byte, b []byte) string {
+	var s string
+	switch enc {
+	case 0: // ISO-8859-1
+		runes := make([]rune, len(b))
+		for i, c := range b {
+			runes[i] = rune(c)
+		}
+		s = string(runes)
+	case 1, 2: // UTF-16 with BOM, UTF-16BE
+		bigEndian := enc == 2
+		if len(b) >= 2 && b[0] == 0xff && b[1] == 0xfe {
+			b, bigEndian = b[2:], false
+		} else if len(b) >= 2 && b[0] == 0xfe && b[1] == 0xff {
+			b, bigEndian = b[2:], true
+		}
+		units := make([]uint16, len(b)/2)
+		for i := range units {
+			if bigEndian {
+				units[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+			} else {
+				units[i] = uint16(b[2*i+1])<<8 | uint16(b[2*i])
+			}
+		}
+		s = string(utf16.Decode(units))
+	default:
+		s = string(b)
+	}
+	s = strings.TrimRight(s, "\x00")
+	return strings.ReplaceAll(s, "\x00", "/")
+}
+
+// parseDate parses the ID3v2.4 timestamp formats, from "2006" to "2006-01-02T15:04:05".
+func parseDate(s string) time.Time {
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02T15", "2006-01-02", "2006-01", "2006"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -2,13 +2,15 @@ package ipfsclient
 
 import (
 	"encoding/json"
-	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/vrypan/lemon3/errs"
+	"github.com/vrypan/lemon3/output"
 )
 
 type AddResponse struct {
@@ -39,7 +41,17 @@ func (pr *ProgressReader) Read(p []byte) (int, error) {
 	return n, err
 }
 
+// AddFile uploads a file to the node, and returns its CID.
 func AddFile(filePath string) (string, error) {
+	return addFile(filePath, false)
+}
+
+// HashFile returns the CID AddFile would return for a file, without storing it.
+func HashFile(filePath string) (string, error) {
+	return addFile(filePath, true)
+}
+
+func addFile(filePath string, onlyHash bool) (string, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return "", err
@@ -51,6 +63,13 @@ func AddFile(filePath string) (string, error) {
 		return "", err
 	}
 
+	url := kuboAPI + "/add"
+	verb := "Upload"
+	if onlyHash {
+		url += "?only-hash=true"
+		verb = "Hash"
+	}
+
 	pr, pw := io.Pipe()
 	writer := multipart.NewWriter(pw)
 
@@ -65,21 +84,24 @@ func AddFile(filePath string) (string, error) {
 		}
 
 		progressReader := &ProgressReader{
-			Reader:   file,
-			Total:    stat.Size(),
-			Callback: func(percent float64) { fmt.Printf("\r[^] Uploading %s: %.1f%%", filePath, percent) },
+			Reader: file,
+			Total:  stat.Size(),
+			Callback: func(percent float64) {
+				output.Progress(
+					output.Fields{"event": "upload", "file": filePath, "percent": percent, "total": stat.Size()},
+					"[^] %sing %s: %.1f%%", verb, filePath, percent,
+				)
+			},
 		}
 
 		if _, err := io.Copy(part, progressReader); err != nil {
 			pw.CloseWithError(err)
 			return
 		}
-		fmt.Printf(" ")
-
 		writer.Close()
 	}()
 
-	req, err := http.NewRequest("POST", kuboAPI+"/add", pr)
+	req, err := http.NewRequest("POST", url, pr)
 	if err != nil {
 		return "", err
 	}
@@ -87,19 +109,20 @@ func AddFile(filePath string) (string, error) {
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return "", err
+		return "", errs.Wrap(errs.Network, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		rb, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("upload failed: %s", string(rb))
+		return "", responseError("upload", rb)
 	}
 
 	var result AddResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return "", err
 	}
-	fmt.Printf(" (cid=%s)\n", result.Hash)
+	output.Status("added", output.Fields{"file": filePath, "cid": result.Hash, "stored": !onlyHash},
+		"\r[^] %sed %s (cid=%s)", verb, filePath, result.Hash)
 	return result.Hash, nil
 }
@@ -4,18 +4,20 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
+
+	"github.com/vrypan/lemon3/errs"
 )
 
 // https://docs.ipfs.tech/reference/kubo/rpc/#getting-started
 var kuboAPI string
 
-func Init(apiUrl string) {
+// Init sets the Kubo RPC endpoint, and checks that it works.
+func Init(apiUrl string) error {
 	kuboAPI = apiUrl
-	if err := testConnection(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	if err := testConnection(kuboAPI); err != nil {
+		return fmt.Errorf("IPFS node %s: %w", apiUrl, err)
 	}
+	return nil
 }
 func Initialized() bool {
 	if kuboAPI != "" {
@@ -24,23 +26,28 @@ func Initialized() bool {
 	return false
 }
 
-func testConnection() error {
-	url := fmt.Sprintf("%s/id", kuboAPI)
+// Ping checks that apiUrl is a working Kubo RPC endpoint, without initializing the client.
+func Ping(apiUrl string) error {
+	return testConnection(apiUrl)
+}
+
+func testConnection(api string) error {
+	url := fmt.Sprintf("%s/id", api)
 	req, err := http.NewRequest("POST", url, nil)
 	if err != nil {
-		return err
+		return errs.Wrap(errs.Config, err)
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return err
+		return errs.Wrap(errs.Network, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("%s", string(body))
+		return errs.New(errs.Network, "%s", string(body))
 	}
 	return nil
 }
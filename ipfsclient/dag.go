@@ -2,11 +2,15 @@ package ipfsclient
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
+	"time"
+
+	"github.com/vrypan/lemon3/errs"
 )
 
 // dagPut serializes JSON to DAG-CBOR and stores it
@@ -38,13 +42,13 @@ func DagPut(obj map[string]any) (string, error) {
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return "", err
+		return "", errs.Wrap(errs.Network, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("dag/put failed: %s", string(body))
+		return "", responseError("dag/put", body)
 	}
 
 	var result struct {
@@ -60,8 +64,26 @@ func DagPut(obj map[string]any) (string, error) {
 
 // dagGet fetches a DAG object as JSON
 func DagGet(cid string) (map[string]any, error) {
+	return dagGet(context.Background(), cid)
+}
+
+/*
+DagGetTimeout is DagGet for blocks that may not be available: Kubo keeps
+searching the network for a missing block, so it fails after timeout.
+*/
+func DagGetTimeout(cid string, timeout time.Duration) (map[string]any, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	result, err := dagGet(ctx, cid)
+	if err != nil && ctx.Err() != nil {
+		return nil, errs.New(errs.Network, "%s not available after %s", cid, timeout)
+	}
+	return result, err
+}
+
+func dagGet(ctx context.Context, cid string) (map[string]any, error) {
 	url := fmt.Sprintf("%s/dag/get?arg=%s", kuboAPI, cid)
-	req, err := http.NewRequest("POST", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -69,13 +91,13 @@ func DagGet(cid string) (map[string]any, error) {
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, errs.Wrap(errs.Network, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("dag/get failed: %s", string(body))
+		return nil, responseError("dag/get", body)
 	}
 
 	var result map[string]any
@@ -0,0 +1,34 @@
+package ipfsclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vrypan/lemon3/errs"
+)
+
+func TestDagGetTimeout(t *testing.T) {
+	// Kubo searches the network for a missing block until the request is canceled.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/dag/get") {
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	kuboAPI = srv.URL
+	defer func() { kuboAPI = "" }()
+
+	start := time.Now()
+	_, err := DagGetTimeout("bafymissing", 50*time.Millisecond)
+	if errs.KindOf(err) != errs.Network || !strings.Contains(err.Error(), "bafymissing") {
+		t.Fatalf("expected a network error naming the CID, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("DagGetTimeout did not give up")
+	}
+}
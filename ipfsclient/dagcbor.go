@@ -0,0 +1,128 @@
+package ipfsclient
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+/*
+DagCid returns the CID DagPut would return for obj, without storing
+anything: obj is converted to JSON and encoded as DAG-CBOR, the way
+Kubo's json input codec does, and hashed with SHA2-256 into a CIDv1.
+
+Like with DagPut, {"/": cid} objects are stored as maps holding a
+string, not as links.
+*/
+func DagCid(obj map[string]any) (string, error) {
+	payload, err := json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var node any
+	if err := dec.Decode(&node); err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := encodeCbor(&buf, node); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	cid := append([]byte{0x01, 0x71, 0x12, 0x20}, sum[:]...) // CIDv1, dag-cbor, sha2-256
+	return "b" + strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(cid)), nil
+}
+
+func cborHead(buf *bytes.Buffer, major byte, n uint64) {
+	switch {
+	case n < 24:
+		buf.WriteByte(major<<5 | byte(n))
+	case n <= math.MaxUint8:
+		buf.WriteByte(major<<5 | 24)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(major<<5 | 25)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	case n <= math.MaxUint32:
+		buf.WriteByte(major<<5 | 26)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	default:
+		buf.WriteByte(major<<5 | 27)
+		buf.Write(binary.BigEndian.AppendUint64(nil, n))
+	}
+}
+
+// encodeCbor writes a value decoded from JSON (with json.Number) as DAG-CBOR.
+func encodeCbor(buf *bytes.Buffer, v any) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(0xf6)
+	case bool:
+		if v {
+			buf.WriteByte(0xf5)
+		} else {
+			buf.WriteByte(0xf4)
+		}
+	case string:
+		cborHead(buf, 3, uint64(len(v)))
+		buf.WriteString(v)
+	case json.Number:
+		// Numbers without a fraction or exponent are integers.
+		if !strings.ContainsAny(string(v), ".eE") {
+			n, ok := new(big.Int).SetString(string(v), 10)
+			if !ok || !n.IsInt64() && !n.IsUint64() {
+				return fmt.Errorf("integer %s out of range", v)
+			}
+			if n.Sign() >= 0 {
+				cborHead(buf, 0, n.Uint64())
+			} else {
+				cborHead(buf, 1, uint64(-(n.Int64() + 1)))
+			}
+			return nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return err
+		}
+		buf.WriteByte(0xfb) // DAG-CBOR floats are always 64 bit
+		buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+	case []any:
+		cborHead(buf, 4, uint64(len(v)))
+		for _, item := range v {
+			if err := encodeCbor(buf, item); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		// Keys are sorted by length, then bytewise.
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if len(keys[i]) != len(keys[j]) {
+				return len(keys[i]) < len(keys[j])
+			}
+			return keys[i] < keys[j]
+		})
+		cborHead(buf, 5, uint64(len(v)))
+		for _, k := range keys {
+			cborHead(buf, 3, uint64(len(k)))
+			buf.WriteString(k)
+			if err := encodeCbor(buf, v[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported JSON value %T", v)
+	}
+	return nil
+}
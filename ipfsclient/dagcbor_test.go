@@ -0,0 +1,69 @@
+package ipfsclient
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+)
+
+func TestDagCidEmpty(t *testing.T) {
+	// The well-known CID of an empty DAG-CBOR map.
+	cid, err := DagCid(map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cid != "bafyreigbtj4x7ip5legnfznufuopl4sg4knzc2cof6duas4b3q2fy6swua" {
+		t.Errorf("unexpected CID %s", cid)
+	}
+}
+
+func TestDagCidMetadata(t *testing.T) {
+	// The CID "ipfs dag put --store-codec=dag-cbor --input-codec=json" returns for this object.
+	const expected = "bafyreiarcmkxtt74iytx35auyffdqfl5eag4rjdzefmluwaww2tr7p4rv4"
+	image := "bafkreigh2akiscaildcqabsyg3dfr6chu3fgpregiymsck7e7aqa4s52zy"
+	cid, err := DagCid(map[string]any{
+		"title":            "Episode 1",
+		"description":      "The first episode.",
+		"type":             "audio/mpeg",
+		"filename":         "ep01.mp3",
+		"size":             int64(29491200),
+		"enclosed":         map[string]string{"/": "QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG"},
+		"artwork":          map[string]string{"/": image},
+		"artwork_width":    1024,
+		"artwork_height":   1024,
+		"thumbnail":        map[string]string{"/": image},
+		"thumbnail_width":  256,
+		"thumbnail_height": 256,
+		"duration":         1843.216,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cid != expected {
+		t.Errorf("got CID %s, expected %s", cid, expected)
+	}
+}
+
+func TestEncodeCbor(t *testing.T) {
+	input := `{"size": 1000, "title": "a", "duration": 1.5, "n": -1, "enclosed": {"/": "Qm"}}`
+	dec := json.NewDecoder(bytes.NewReader([]byte(input)))
+	dec.UseNumber()
+	var node any
+	if err := dec.Decode(&node); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := encodeCbor(&buf, node); err != nil {
+		t.Fatal(err)
+	}
+	expected := "a5" + // map of 5, keys sorted by length
+		"616e" + "20" + // n: -1
+		"6473697a65" + "1903e8" + // size: 1000
+		"657469746c65" + "6161" + // title: "a"
+		"686475726174696f6e" + "fb3ff8000000000000" + // duration: 1.5
+		"68656e636c6f736564" + "a1" + "612f" + "62516d" // enclosed: {"/": "Qm"}, a map, not a link
+	if got := hex.EncodeToString(buf.Bytes()); got != expected {
+		t.Errorf("got      %s\nexpected %s", got, expected)
+	}
+}
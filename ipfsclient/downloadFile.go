@@ -1,24 +1,26 @@
 package ipfsclient
 
 import (
-	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"time"
+
+	"github.com/vrypan/lemon3/errs"
+	"github.com/vrypan/lemon3/output"
 )
 
 func CatCIDToFile(cid, outFile string, size int64) error {
 	resp, err := http.Post(kuboAPI+"/cat?arg="+url.QueryEscape(cid), "application/x-www-form-urlencoded", nil)
 	if err != nil {
-		return err
+		return errs.Wrap(errs.Network, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		rb, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("cat failed: %s", string(rb))
+		return responseError("cat", rb)
 	}
 
 	file, err := os.Create(outFile)
@@ -29,26 +31,32 @@ func CatCIDToFile(cid, outFile string, size int64) error {
 
 	// Spinner + progress
 	done := make(chan struct{})
+	finished := make(chan struct{})
 	progress := make(chan int64)
 
 	go func() {
 		spin := []rune{'|', '/', '-', '\\'}
 		i := 0
 		var downloaded int64
+		var last time.Time
 		for {
 			select {
 			case <-done:
-				fmt.Printf("\r[✓] Downloaded %d / %d bytes (100.0%%)                         \n", size, size)
+				close(finished)
 				return
 			case downloaded = <-progress:
-				percentage := (float64(downloaded) / float64(size)) * 100
-				fmt.Printf("\r[%c] Downloading... %d / %d bytes (%.1f%%)", spin[i%len(spin)], downloaded, size, percentage)
-				i++
 			case <-time.After(100 * time.Millisecond):
-				percentage := (float64(downloaded) / float64(size)) * 100
-				fmt.Printf("\r[%c] Downloading... %d / %d bytes (%.1f%%)", spin[i%len(spin)], downloaded, size, percentage)
-				i++
 			}
+			if time.Since(last) < 100*time.Millisecond {
+				continue
+			}
+			last = time.Now()
+			percentage := (float64(downloaded) / float64(size)) * 100
+			output.Progress(
+				output.Fields{"event": "download", "cid": cid, "file": outFile, "bytes": downloaded, "total": size, "percent": percentage},
+				"[%c] Downloading... %d / %d bytes (%.1f%%)", spin[i%len(spin)], downloaded, size, percentage,
+			)
+			i++
 		}
 	}()
 
@@ -56,7 +64,14 @@ func CatCIDToFile(cid, outFile string, size int64) error {
 	countingReader := &countReader{Reader: resp.Body, progress: progress}
 	_, err = io.Copy(file, countingReader)
 	close(done)
-	return err
+	<-finished
+	if err != nil {
+		output.EndProgress()
+		return err
+	}
+	output.Status("downloaded", output.Fields{"cid": cid, "file": outFile, "size": size},
+		"\r[✓] Downloaded %d / %d bytes (100.0%%)                         ", size, size)
+	return nil
 }
 
 // countReader wraps an io.Reader and sends progress updates
@@ -0,0 +1,21 @@
+package ipfsclient
+
+import (
+	"strings"
+
+	"github.com/vrypan/lemon3/errs"
+)
+
+// responseError classifies the error message of a failed Kubo RPC call.
+func responseError(op string, body []byte) error {
+	msg := strings.TrimSpace(string(body))
+	lower := strings.ToLower(msg)
+	switch {
+	case strings.Contains(lower, "invalid cid"), strings.Contains(lower, "invalid path"),
+		strings.Contains(lower, "selected encoding not supported"):
+		return errs.New(errs.UserInput, "%s failed: %s", op, msg)
+	case strings.Contains(lower, "not found"):
+		return errs.New(errs.NotFound, "%s failed: %s", op, msg)
+	}
+	return errs.New(errs.Network, "%s failed: %s", op, msg)
+}
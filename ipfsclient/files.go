@@ -1,35 +1,52 @@
 package ipfsclient
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
+
+	"github.com/vrypan/lemon3/errs"
 )
 
 func PinCID(cid string) error {
 	resp, err := http.Post(kuboAPI+"/pin/add?arg="+cid, "", nil)
 	if err != nil {
-		return err
+		return errs.Wrap(errs.Network, err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		rb, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("pin failed: %s", string(rb))
+		return responseError("pin", rb)
 	}
 	return nil
 }
 
+// UnpinCID removes the recursive pin of cid. It is not an error if cid is not pinned.
+func UnpinCID(cid string) error {
+	var result struct {
+		Pins []string `json:"Pins"`
+	}
+	err := rpc("/pin/rm?arg="+url.QueryEscape(cid), &result)
+	if err != nil && strings.Contains(err.Error(), "not pinned") {
+		return nil
+	}
+	return err
+}
+
 func CatCID(cid string) ([]byte, error) {
 	resp, err := http.Post(kuboAPI+"/cat?arg="+url.QueryEscape(cid), "application/x-www-form-urlencoded", nil)
 	if err != nil {
-		return nil, err
+		return nil, errs.Wrap(errs.Network, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		rb, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("cat failed: %s", string(rb))
+		return nil, responseError("cat", rb)
 	}
 	return io.ReadAll(resp.Body)
 }
@@ -45,13 +62,49 @@ func ProvideCIDRecursive(cid string) error {
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return err
+		return errs.Wrap(errs.Network, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("dht/provide failed: %s", string(body))
+		return responseError("dht/provide", body)
 	}
 	return nil
 }
+
+// IsPinned checks if cid is pinned recursively on the node.
+func IsPinned(cid string) (bool, error) {
+	var result struct {
+		Keys map[string]any `json:"Keys"`
+	}
+	err := rpc("/pin/ls?type=recursive&arg="+url.QueryEscape(cid), &result)
+	if err != nil {
+		if strings.Contains(err.Error(), "not pinned") {
+			return false, nil
+		}
+		return false, err
+	}
+	return len(result.Keys) > 0, nil
+}
+
+type StatResponse struct {
+	Hash           string `json:"Hash"`
+	Size           int64  `json:"Size"`
+	CumulativeSize int64  `json:"CumulativeSize"`
+	Type           string `json:"Type"`
+}
+
+// Stat returns the size and type of a UnixFS file or directory, without downloading it.
+func Stat(cid string, timeout time.Duration) (*StatResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var result StatResponse
+	if err := rpcContext(ctx, "/files/stat?arg="+url.QueryEscape("/ipfs/"+cid), &result); err != nil {
+		if ctx.Err() != nil {
+			return nil, errs.New(errs.Network, "%s not available after %s", cid, timeout)
+		}
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,154 @@
+package ipfsclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/vrypan/lemon3/errs"
+)
+
+type IdResponse struct {
+	ID           string   `json:"ID"`
+	Addresses    []string `json:"Addresses"`
+	AgentVersion string   `json:"AgentVersion"`
+}
+
+// rpc calls a Kubo RPC method and decodes the JSON response into result.
+func rpc(method string, result any) error {
+	return rpcContext(context.Background(), method, result)
+}
+
+// rpcContext is rpc for calls that may search the network, and need a deadline.
+func rpcContext(ctx context.Context, method string, result any) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", kuboAPI+method, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errs.Wrap(errs.Network, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return responseError(strings.SplitN(method, "?", 2)[0], body)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// Id returns the peer ID, addresses and agent version of the node.
+func Id() (*IdResponse, error) {
+	var result IdResponse
+	if err := rpc("/id", &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Version returns the Kubo version.
+func Version() (string, error) {
+	var result struct {
+		Version string `json:"Version"`
+	}
+	if err := rpc("/version", &result); err != nil {
+		return "", err
+	}
+	return result.Version, nil
+}
+
+// SwarmPeerCount returns the number of peers the node is connected to.
+func SwarmPeerCount() (int, error) {
+	var result struct {
+		Peers []json.RawMessage `json:"Peers"`
+	}
+	if err := rpc("/swarm/peers", &result); err != nil {
+		return 0, err
+	}
+	return len(result.Peers), nil
+}
+
+/*
+PublicAddresses returns the addresses of the node that other peers can
+dial directly: not loopback, private or relayed (p2p-circuit).
+*/
+func PublicAddresses(id *IdResponse) []string {
+	public := []string{}
+	for _, a := range id.Addresses {
+		if strings.Contains(a, "/p2p-circuit") {
+			continue
+		}
+		parts := strings.Split(a, "/")
+		if len(parts) < 3 || (parts[1] != "ip4" && parts[1] != "ip6") {
+			continue
+		}
+		ip := net.ParseIP(parts[2])
+		if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+			continue
+		}
+		public = append(public, a)
+	}
+	return public
+}
+
+// IsRelayed checks if the node is reachable through a relay.
+func IsRelayed(id *IdResponse) bool {
+	for _, a := range id.Addresses {
+		if strings.Contains(a, "/p2p-circuit") {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+FindProviders searches the DHT for peers that provide cid, and returns
+how many were found, up to max, before the timeout.
+*/
+func FindProviders(cid string, max int, timeout time.Duration) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	method := fmt.Sprintf("/routing/findprovs?arg=%s&num-providers=%d", url.QueryEscape(cid), max)
+	req, err := http.NewRequestWithContext(ctx, "POST", kuboAPI+method, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, errs.Wrap(errs.Network, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return 0, responseError("routing/findprovs", body)
+	}
+
+	// The response is a stream of routing events; type 4 events list providers.
+	providers := map[string]bool{}
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var event struct {
+			Type      int
+			Responses []struct{ ID string }
+		}
+		if err := decoder.Decode(&event); err != nil {
+			break // end of stream, or timeout
+		}
+		if event.Type != 4 {
+			continue
+		}
+		for _, r := range event.Responses {
+			providers[r.ID] = true
+		}
+	}
+	return len(providers), nil
+}
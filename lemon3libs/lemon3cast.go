@@ -2,7 +2,6 @@ package lemon3libs
 
 import (
 	"encoding/json"
-	"fmt"
 	"strings"
 
 	pb "github.com/vrypan/farcaster-go/farcaster"
@@ -21,23 +20,15 @@ type L3Cast struct {
 }
 
 func FromPbMessage(msg *pb.Message) (*L3Cast, error) {
-	l3c := L3Cast{}
-	cid := l3CidFromCast(msg.Data.GetCastAddBody())
+	cid := CidFromMessage(msg)
 	if cid == "" {
 		return nil, nil
 	}
-	l3c.Lemon3Cid = cid
-	l3c.Fid = msg.Data.Fid
-	l3c.Timestamp = uint64(msg.Data.Timestamp) + uint64(FARCASTER_EPOCH)
-	l3c.Hash = fmt.Sprintf("0x%x", msg.Hash)
-	l3c.Text = msg.Data.GetCastAddBody().Text
-
-	var err error
-	l3c.Lemon3Data, err = FromCid(cid)
+	meta, err := FromCid(cid)
 	if err != nil {
 		return nil, err
 	}
-	return &l3c, nil
+	return NewL3Cast(msg, meta), nil
 }
 
 func (c *L3Cast) ToJSON() (string, error) {
@@ -57,6 +48,11 @@ func FromJSON(input string) (*L3Cast, error) {
 	return &cast, nil
 }
 
+// CidFromMessage returns the lemon3 metadata CID embedded in a cast, or "".
+func CidFromMessage(msg *pb.Message) string {
+	return l3CidFromCast(msg.Data.GetCastAddBody())
+}
+
 func l3CidFromCast(cast *pb.CastAddBody) string {
 	embeds := cast.Embeds
 	for _, e := range embeds {
@@ -2,10 +2,11 @@ package lemon3libs
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
-	"os"
+	"strings"
+	"time"
 
+	"github.com/vrypan/lemon3/errs"
 	"github.com/vrypan/lemon3/ipfsclient"
 )
 
@@ -15,8 +16,17 @@ type Lemon3Metadata struct {
 	Type        string            `json:"type"`
 	Filename    string            `json:"filename"`
 	Size        int64             `json:"size"`
+	Duration    float64           `json:"duration,omitempty"` // seconds, for audio and video
 	Enclosed    map[string]string `json:"enclosed"`
-	Artwork     map[string]string `json:"artwork"`
+	Artwork     map[string]string `json:"artwork,omitempty"` // nil if the upload has no artwork
+	Thumbnail   map[string]string `json:"thumbnail,omitempty"`
+	Previous    map[string]string `json:"previous,omitempty"` // the metadata this revision replaces
+
+	// Dimensions in pixels, when known.
+	ArtworkWidth    int `json:"artwork_width,omitempty"`
+	ArtworkHeight   int `json:"artwork_height,omitempty"`
+	ThumbnailWidth  int `json:"thumbnail_width,omitempty"`
+	ThumbnailHeight int `json:"thumbnail_height,omitempty"`
 }
 
 func (m *Lemon3Metadata) ToJSON() []byte {
@@ -27,6 +37,39 @@ func (m *Lemon3Metadata) ToJSON() []byte {
 	return data
 }
 
+// Validate checks that the metadata has the fields clients need to present the file.
+func (m *Lemon3Metadata) Validate() error {
+	problems := []string{}
+	if m.Title == "" {
+		problems = append(problems, "title is empty")
+	}
+	if m.Type == "" {
+		problems = append(problems, "type is empty")
+	}
+	if m.Filename == "" || m.Filename == m.Enclosed["/"] {
+		problems = append(problems, "filename is missing")
+	}
+	if m.Size <= 0 {
+		problems = append(problems, "size is missing")
+	}
+	if !IsCid(m.Enclosed["/"]) {
+		problems = append(problems, "enclosed is not a CID")
+	}
+	if m.Artwork["/"] != "" && !IsCid(m.Artwork["/"]) {
+		problems = append(problems, "artwork is not a CID")
+	}
+	if m.Thumbnail["/"] != "" && !IsCid(m.Thumbnail["/"]) {
+		problems = append(problems, "thumbnail is not a CID")
+	}
+	if m.Previous["/"] != "" && !IsCid(m.Previous["/"]) {
+		problems = append(problems, "previous is not a CID")
+	}
+	if len(problems) > 0 {
+		return errs.New(errs.Verification, "%s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
 /*
 Given a lemon3 DAG CID, fetch the data from IPFS and return
 a Lemon3Metadata object.
@@ -34,37 +77,46 @@ a Lemon3Metadata object.
 func FromCid(cid string) (*Lemon3Metadata, error) {
 
 	if !ipfsclient.Initialized() {
-		fmt.Println("Error: lemon3libs.FromCid called without initializing ipfsclient.")
-		os.Exit(1)
+		return nil, errs.New(errs.Config, "ipfs client is not initialized")
 	}
 	metadata, err := ipfsclient.DagGet(cid)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch DAG: %w", err)
 	}
+	return fromDag(metadata)
+}
 
+// FromCidTimeout is FromCid, but fails if the metadata is not found within timeout.
+func FromCidTimeout(cid string, timeout time.Duration) (*Lemon3Metadata, error) {
+	if !ipfsclient.Initialized() {
+		return nil, errs.New(errs.Config, "ipfs client is not initialized")
+	}
+	metadata, err := ipfsclient.DagGetTimeout(cid, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch DAG: %w", err)
+	}
+	return fromDag(metadata)
+}
+
+func fromDag(metadata map[string]any) (*Lemon3Metadata, error) {
 	// Extract and validate fields
 	enclosedField, ok := metadata["enclosed"]
 	if !ok {
-		return nil, errors.New("DAG does not contain 'enclosed' field")
+		return nil, errs.New(errs.Verification, "DAG does not contain 'enclosed' field")
 	}
 	enclosedMap, ok := enclosedField.(map[string]any)
 	if !ok {
-		return nil, errors.New("'enclosed' field is not the expected map structure")
+		return nil, errs.New(errs.Verification, "'enclosed' field is not the expected map structure")
 	}
 	enclosed, ok := enclosedMap["/"].(string)
 	if !ok {
-		return nil, errors.New("DAG does not contain valid 'enclosed' CID in 'enclosed' field")
+		return nil, errs.New(errs.Verification, "DAG does not contain valid 'enclosed' CID in 'enclosed' field")
 	}
 
-	// Optional: Artwork
-	var artwork string
-	if artworkField, ok := metadata["artwork"]; ok {
-		if artworkMap, ok := artworkField.(map[string]any); ok {
-			if val, ok := artworkMap["/"].(string); ok {
-				artwork = val
-			}
-		}
-	}
+	// Optional: Artwork and thumbnail
+	artwork := link(metadata, "artwork")
+	thumbnail := link(metadata, "thumbnail")
+	previous := link(metadata, "previous")
 
 	// Other fields
 	title, _ := metadata["title"].(string)
@@ -74,13 +126,9 @@ func FromCid(cid string) (*Lemon3Metadata, error) {
 	if filename == "" {
 		filename = enclosed // fallback
 	}
-	size, _ := metadata["size"].(int64)
-	if size == 0 {
-		// JSON numbers come as float64 — handle conversion safely
-		if f, ok := metadata["size"].(float64); ok {
-			size = int64(f)
-		}
-	}
+	size := number(metadata, "size")
+
+	duration, _ := metadata["duration"].(float64)
 
 	return &Lemon3Metadata{
 		Title:       title,
@@ -88,7 +136,36 @@ func FromCid(cid string) (*Lemon3Metadata, error) {
 		Type:        mimeType,
 		Filename:    filename,
 		Size:        size,
+		Duration:    duration,
 		Enclosed:    map[string]string{"/": enclosed},
-		Artwork:     map[string]string{"/": artwork},
+		Artwork:     artwork,
+		Thumbnail:   thumbnail,
+		Previous:    previous,
+
+		ArtworkWidth:    int(number(metadata, "artwork_width")),
+		ArtworkHeight:   int(number(metadata, "artwork_height")),
+		ThumbnailWidth:  int(number(metadata, "thumbnail_width")),
+		ThumbnailHeight: int(number(metadata, "thumbnail_height")),
 	}, nil
 }
+
+// link returns the {"/": cid} link in field, or nil if it is missing.
+func link(metadata map[string]any, field string) map[string]string {
+	if m, ok := metadata[field].(map[string]any); ok {
+		if val, ok := m["/"].(string); ok && val != "" {
+			return map[string]string{"/": val}
+		}
+	}
+	return nil
+}
+
+// number returns an integer field of the metadata, or 0.
+func number(metadata map[string]any, field string) int64 {
+	switch v := metadata[field].(type) {
+	case int64:
+		return v
+	case float64: // JSON numbers come as float64
+		return int64(v)
+	}
+	return 0
+}
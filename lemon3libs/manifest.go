@@ -0,0 +1,204 @@
+package lemon3libs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/vrypan/lemon3/errs"
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestEntry is a file to upload with "lemon3 upload --manifest".
+type ManifestEntry struct {
+	File        string `yaml:"file" json:"file"`
+	Title       string `yaml:"title,omitempty" json:"title,omitempty"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Artwork     string `yaml:"artwork,omitempty" json:"artwork,omitempty"`
+	Cast        string `yaml:"cast,omitempty" json:"cast,omitempty"`
+	Channel     string `yaml:"channel,omitempty" json:"channel,omitempty"`
+	At          string `yaml:"at,omitempty" json:"at,omitempty"` // publish the cast at this time, see ParseTime
+
+	Time time.Time `yaml:"-" json:"-"` // At, parsed by LoadManifest
+}
+
+/*
+Manifest is a list of uploads. Defaults apply to every entry that
+does not set the same field.
+
+	defaults:
+	  artwork: cover.jpg
+	  channel: podcasts
+	entries:
+	  - file: ep01.mp3
+	    title: Episode 1
+	    at: 2026-11-01T09:00:00Z
+*/
+type Manifest struct {
+	Defaults ManifestEntry   `yaml:"defaults,omitempty"`
+	Entries  []ManifestEntry `yaml:"entries"`
+
+	Path string `yaml:"-"` // the manifest file
+}
+
+/*
+LoadManifest reads a YAML (or JSON) manifest. File and artwork paths are
+made relative to the directory of the manifest, and defaults are applied.
+*/
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errs.Wrap(errs.UserInput, err)
+	}
+	m := &Manifest{Path: path}
+	if err := yaml.Unmarshal(data, m); err != nil {
+		return nil, errs.New(errs.UserInput, "invalid manifest %s: %w", path, err)
+	}
+	if len(m.Entries) == 0 {
+		return nil, errs.New(errs.UserInput, "manifest %s has no entries", path)
+	}
+
+	dir := filepath.Dir(path)
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+	seen := map[string]int{}
+	for i := range m.Entries {
+		e := &m.Entries[i]
+		if e.File == "" {
+			return nil, errs.New(errs.UserInput, "manifest entry %d has no file", i+1)
+		}
+		if j, ok := seen[e.File]; ok {
+			return nil, errs.New(errs.UserInput, "manifest entries %d and %d upload the same file %s", j+1, i+1, e.File)
+		}
+		seen[e.File] = i
+		if e.At != "" {
+			if e.Time, err = ParseTime(e.At); err != nil {
+				return nil, errs.New(errs.UserInput, "manifest entry %d: %w", i+1, err)
+			}
+		}
+		e.Title = or(e.Title, m.Defaults.Title)
+		e.Description = or(e.Description, m.Defaults.Description)
+		e.Artwork = resolve(or(e.Artwork, m.Defaults.Artwork))
+		e.Cast = or(e.Cast, m.Defaults.Cast)
+		e.Channel = or(e.Channel, m.Defaults.Channel)
+		if strings.HasPrefix(e.Description, "@") && e.Description != "@-" {
+			e.Description = "@" + resolve(e.Description[1:])
+		}
+	}
+	return m, nil
+}
+
+// FilePath returns the path of the file of an entry.
+func (m *Manifest) FilePath(e ManifestEntry) string {
+	if filepath.IsAbs(e.File) {
+		return e.File
+	}
+	return filepath.Join(filepath.Dir(m.Path), e.File)
+}
+
+/*
+ParseTime parses a publication time: RFC 3339 with or without seconds
+(2026-11-01T09:00Z, 2026-11-01T11:00:00+02:00), or a local time
+(2026-11-01 09:00, 2026-11-01T09:00).
+*/
+func ParseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04Z07:00"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02T15:04:05"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, use 2006-01-02T15:04Z or 2006-01-02 15:04", s)
+}
+
+func or(value, fallback string) string {
+	if value != "" {
+		return value
+	}
+	return fallback
+}
+
+// JournalEntry records what has been done for a manifest entry.
+type JournalEntry struct {
+	Size      int64     `json:"size"`     // size and modification time of the file when
+	Modified  time.Time `json:"modified"` // it was uploaded, to detect changes
+	Filename  string    `json:"filename,omitempty"`
+	Enclosed  string    `json:"enclosed,omitempty"`
+	Artwork   string    `json:"artwork,omitempty"`
+	Thumbnail string    `json:"thumbnail,omitempty"`
+	Metadata  string    `json:"metadata,omitempty"`
+	Cast      string    `json:"cast,omitempty"` // @user/0xhash
+	CastAt    time.Time `json:"cast_at,omitzero"`
+	Queued    string    `json:"queued,omitempty"` // queue id, if the cast is scheduled
+}
+
+/*
+Journal tracks the progress of a manifest upload, so that an interrupted
+upload can resume without uploading the same files again. It is saved
+next to the manifest, as <manifest>.journal.
+*/
+type Journal struct {
+	Entries map[string]*JournalEntry `json:"entries"` // by ManifestEntry.File
+
+	path string
+}
+
+// LoadJournal reads the journal of a manifest, or returns an empty one.
+func LoadJournal(manifestPath string) (*Journal, error) {
+	j := &Journal{Entries: map[string]*JournalEntry{}, path: manifestPath + ".journal"}
+	data, err := os.ReadFile(j.path)
+	if os.IsNotExist(err) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, j); err != nil {
+		return nil, fmt.Errorf("invalid journal %s: %w", j.path, err)
+	}
+	if j.Entries == nil {
+		j.Entries = map[string]*JournalEntry{}
+	}
+	return j, nil
+}
+
+func (j *Journal) Path() string {
+	return j.path
+}
+
+/*
+Uploaded returns the journal entry of file if it was uploaded, and the file
+has not changed since. info is the current state of the file.
+*/
+func (j *Journal) Uploaded(file string, info os.FileInfo) *JournalEntry {
+	e := j.Entries[file]
+	if e == nil || e.Metadata == "" || e.Size != info.Size() || !e.Modified.Equal(info.ModTime()) {
+		return nil
+	}
+	return e
+}
+
+// Save writes the journal, replacing the previous version atomically.
+func (j *Journal) Save() error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, j.path)
+}
@@ -0,0 +1,101 @@
+package lemon3libs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadManifest(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "episodes.yaml")
+	os.WriteFile(path, []byte(`
+defaults:
+  artwork: cover.jpg
+  channel: podcasts
+  cast: New episode
+entries:
+  - file: ep01.mp3
+    title: Episode 1
+    description: "@ep01.txt"
+    at: 2026-11-01T09:00Z
+  - file: ep02.mp3
+    artwork: /art/ep02.png
+    channel: music
+`), 0644)
+
+	m, err := LoadManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(m.Entries))
+	}
+	e := m.Entries[0]
+	if m.FilePath(e) != filepath.Join(dir, "ep01.mp3") || e.Artwork != filepath.Join(dir, "cover.jpg") {
+		t.Errorf("paths are not relative to the manifest: %+v", e)
+	}
+	if e.Description != "@"+filepath.Join(dir, "ep01.txt") || e.Channel != "podcasts" || e.Cast != "New episode" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if !e.Time.Equal(time.Date(2026, 11, 1, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("at = %s", e.Time)
+	}
+	if e := m.Entries[1]; e.Artwork != "/art/ep02.png" || e.Channel != "music" || !e.Time.IsZero() {
+		t.Errorf("unexpected entry %+v", e)
+	}
+
+	os.WriteFile(path, []byte("entries:\n  - file: a.mp3\n  - file: a.mp3\n"), 0644)
+	if _, err := LoadManifest(path); err == nil {
+		t.Error("expected an error for duplicate files")
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	for _, s := range []string{"2026-11-01T09:00Z", "2026-11-01T09:00:00Z", "2026-11-01T11:00+02:00"} {
+		if tm, err := ParseTime(s); err != nil || !tm.Equal(time.Date(2026, 11, 1, 9, 0, 0, 0, time.UTC)) {
+			t.Errorf("ParseTime(%q) = %s, %v", s, tm, err)
+		}
+	}
+	if tm, err := ParseTime("2026-11-01 09:00"); err != nil || !tm.Equal(time.Date(2026, 11, 1, 9, 0, 0, 0, time.Local)) {
+		t.Errorf("ParseTime(local) = %s, %v", tm, err)
+	}
+	if _, err := ParseTime("next tuesday"); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestJournal(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "episodes.yaml")
+	file := filepath.Join(dir, "ep01.mp3")
+	os.WriteFile(file, []byte("audio"), 0644)
+	info, _ := os.Stat(file)
+
+	j, err := LoadJournal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.Entries["ep01.mp3"] = &JournalEntry{Size: info.Size(), Modified: info.ModTime(), Metadata: "bafy"}
+	if err := j.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	j, err = LoadJournal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := j.Uploaded("ep01.mp3", info); e == nil || e.Metadata != "bafy" {
+		t.Errorf("expected ep01.mp3 to be uploaded, got %+v", e)
+	}
+	if e := j.Uploaded("ep02.mp3", info); e != nil {
+		t.Errorf("expected ep02.mp3 not to be uploaded")
+	}
+
+	os.WriteFile(file, []byte("new audio"), 0644)
+	info, _ = os.Stat(file)
+	if e := j.Uploaded("ep01.mp3", info); e != nil {
+		t.Errorf("expected a changed file to be uploaded again")
+	}
+}
@@ -0,0 +1,81 @@
+package lemon3libs
+
+import (
+	"html/template"
+	"io"
+	"strings"
+)
+
+// PreviewPage holds the values rendered by RenderPreview.
+type PreviewPage struct {
+	Cid        string
+	Meta       *Lemon3Metadata
+	PageUrl    string // Absolute URL of the preview page
+	ArtworkUrl string // Absolute URL of the artwork, empty if there is none
+	FileUrl    string // Gateway URL of the enclosed file
+}
+
+func (p PreviewPage) Size() string {
+	return HumanSize(p.Meta.Size)
+}
+
+func (p PreviewPage) MediaKind() string {
+	switch {
+	case strings.HasPrefix(p.Meta.Type, "audio/"):
+		return "audio"
+	case strings.HasPrefix(p.Meta.Type, "video/"):
+		return "video"
+	case strings.HasPrefix(p.Meta.Type, "image/"):
+		return "image"
+	}
+	return ""
+}
+
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{ .Meta.Title }}</title>
+<meta property="og:type" content="website">
+<meta property="og:title" content="{{ .Meta.Title }}">
+<meta property="og:description" content="{{ .Meta.Description }}">
+<meta property="og:url" content="{{ .PageUrl }}">
+{{- if .ArtworkUrl }}
+<meta property="og:image" content="{{ .ArtworkUrl }}">
+<meta name="twitter:card" content="summary_large_image">
+{{- end }}
+{{- if eq .MediaKind "audio" }}
+<meta property="og:audio" content="{{ .FileUrl }}">
+<meta property="og:audio:type" content="{{ .Meta.Type }}">
+{{- else if eq .MediaKind "video" }}
+<meta property="og:video" content="{{ .FileUrl }}">
+<meta property="og:video:type" content="{{ .Meta.Type }}">
+{{- end }}
+<style>
+body { font-family: sans-serif; max-width: 40em; margin: 2em auto; padding: 0 1em; }
+img, audio, video { max-width: 100%; }
+pre { white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>{{ .Meta.Title }}</h1>
+{{- if .ArtworkUrl }}
+<p><img src="{{ .ArtworkUrl }}" alt="{{ .Meta.Title }}"></p>
+{{- end }}
+{{- if eq .MediaKind "audio" }}
+<p><audio controls preload="none" src="{{ .FileUrl }}"></audio></p>
+{{- else if eq .MediaKind "video" }}
+<p><video controls preload="none" src="{{ .FileUrl }}"></video></p>
+{{- end }}
+<pre>{{ .Meta.Description }}</pre>
+<p><a href="{{ .FileUrl }}">{{ .Meta.Filename }}</a> ({{ .Meta.Type }}, {{ .Size }})</p>
+<p><code>lemon3+ipfs://{{ .Cid }}</code></p>
+</body>
+</html>
+`))
+
+// RenderPreview writes the HTML/OpenGraph preview page of a lemon3 upload.
+func RenderPreview(w io.Writer, page PreviewPage) error {
+	return previewTemplate.Execute(w, page)
+}
@@ -0,0 +1,123 @@
+package lemon3libs
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/vrypan/lemon3/errs"
+)
+
+/*
+CastRef is a reference to a lemon3 cast, or directly to a lemon3
+metadata CID. Either Fname or Fid, and Hash are set, or Cid is set.
+*/
+type CastRef struct {
+	Fname string // without the @
+	Fid   uint64
+	Hash  string // 0x-prefixed, the full hash or a prefix of it
+	Cid   string
+}
+
+// HashLen is the length of a full cast hash, in bytes.
+const HashLen = 20
+
+// minHashPrefix is the shortest hash prefix accepted, in hex digits.
+const minHashPrefix = 4
+
+func (r CastRef) String() string {
+	if r.Cid != "" {
+		return r.Cid
+	}
+	if r.Fname == "" {
+		return fmt.Sprintf("fid:%d/%s", r.Fid, r.Hash)
+	}
+	return "@" + r.Fname + "/" + r.Hash
+}
+
+// IsShort checks if Hash is a prefix of the cast hash, like the ones in Farcaster URLs.
+func (r CastRef) IsShort() bool {
+	return r.Cid == "" && len(r.Hash) < 2+2*HashLen
+}
+
+var cidRegexp = regexp.MustCompile(`^(Qm[1-9A-HJ-NP-Za-km-z]{44}|b[a-z2-7]{50,})$`)
+
+// IsCid checks if s looks like a CIDv0 (Qm...) or a base32 CIDv1 (bafy...).
+func IsCid(s string) bool {
+	return cidRegexp.MatchString(s)
+}
+
+/*
+ParseCastRef parses one of:
+
+	@user/0x<hash>
+	fid:<fid>/0x<hash>
+	https://farcaster.xyz/user/0x<hash> (or warpcast.com)
+	lemon3+ipfs://<metadata CID>
+	<metadata CID>
+
+The hash can be shortened to its first few digits, like in Farcaster URLs.
+*/
+func ParseCastRef(s string) (CastRef, error) {
+	s = strings.TrimSpace(s)
+	if cid, ok := strings.CutPrefix(s, "lemon3+ipfs://"); ok {
+		if !IsCid(cid) {
+			return CastRef{}, errs.New(errs.UserInput, "invalid CID in %s", s)
+		}
+		return CastRef{Cid: cid}, nil
+	}
+	if IsCid(s) {
+		return CastRef{Cid: s}, nil
+	}
+
+	path := s
+	if u, err := url.Parse(s); err == nil && (u.Scheme == "https" || u.Scheme == "http") {
+		switch strings.TrimPrefix(u.Host, "www.") {
+		case "farcaster.xyz", "warpcast.com":
+			path = "@" + strings.Trim(u.Path, "/")
+		default:
+			return CastRef{}, errs.New(errs.UserInput, "unsupported URL %s", s)
+		}
+	}
+
+	parts := strings.Split(path, "/")
+	if len(parts) != 2 {
+		return CastRef{}, errs.New(errs.UserInput, "invalid cast %q, use @user/<hash>, fid:<fid>/<hash> or a metadata CID", s)
+	}
+	ref := CastRef{}
+	if fid, ok := strings.CutPrefix(parts[0], "fid:"); ok {
+		n, err := strconv.ParseUint(fid, 10, 64)
+		if err != nil || n == 0 {
+			return CastRef{}, errs.New(errs.UserInput, "invalid FID %q", fid)
+		}
+		ref.Fid = n
+	} else if fname, ok := strings.CutPrefix(parts[0], "@"); ok && fname != "" {
+		ref.Fname = fname
+	} else {
+		return CastRef{}, errs.New(errs.UserInput, "invalid cast %q, use @user/<hash>, fid:<fid>/<hash> or a metadata CID", s)
+	}
+
+	digits := strings.TrimPrefix(strings.ToLower(parts[1]), "0x")
+	if len(digits) < minHashPrefix || len(digits) > 2*HashLen || !isHex(digits) {
+		return CastRef{}, errs.New(errs.UserInput, "invalid cast hash %s", parts[1])
+	}
+	ref.Hash = "0x" + digits
+	return ref, nil
+}
+
+// MatchHash checks if the hash of a cast matches ref, which may be a prefix.
+func (r CastRef) MatchHash(hash []byte) bool {
+	return strings.HasPrefix(hex.EncodeToString(hash), strings.TrimPrefix(r.Hash, "0x"))
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
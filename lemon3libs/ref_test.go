@@ -0,0 +1,48 @@
+package lemon3libs
+
+import "testing"
+
+func TestParseCastRef(t *testing.T) {
+	const hash = "0xcd3141a47b98685c292b55c44f932e221753e51b"
+	const cid = "bafyreigh2akiscaildcqabsyg3dfr6chu3fgpregiymsck7e7aqa4s52zy"
+	tests := map[string]CastRef{
+		"@vrypan.eth/" + hash:                            {Fname: "vrypan.eth", Hash: hash},
+		"@vrypan.eth/" + hash[2:]:                        {Fname: "vrypan.eth", Hash: hash},
+		"https://farcaster.xyz/vrypan.eth/" + hash:       {Fname: "vrypan.eth", Hash: hash},
+		"https://warpcast.com/vrypan.eth/" + hash + "/":  {Fname: "vrypan.eth", Hash: hash},
+		"https://farcaster.xyz/vrypan.eth/0xcd3141a4":    {Fname: "vrypan.eth", Hash: "0xcd3141a4"},
+		"fid:280/" + hash:                                {Fid: 280, Hash: hash},
+		"lemon3+ipfs://" + cid:                           {Cid: cid},
+		cid:                                              {Cid: cid},
+		"QmXokMFSAa4KL12nx66RzLeUPpvJs3ghD9fAGnrbCKiHWZ": {Cid: "QmXokMFSAa4KL12nx66RzLeUPpvJs3ghD9fAGnrbCKiHWZ"},
+	}
+	for input, expected := range tests {
+		got, err := ParseCastRef(input)
+		if err != nil {
+			t.Errorf("ParseCastRef(%q): %v", input, err)
+			continue
+		}
+		if got != expected {
+			t.Errorf("ParseCastRef(%q) = %+v, expected %+v", input, got, expected)
+		}
+	}
+
+	for _, input := range []string{"", "vrypan.eth/" + hash, "@vrypan.eth/0xcd3", "@vrypan.eth/0xzz3141a4", "fid:x/" + hash, "lemon3+ipfs://foo", "https://example.com/vrypan.eth/" + hash} {
+		if _, err := ParseCastRef(input); err == nil {
+			t.Errorf("ParseCastRef(%q): expected error", input)
+		}
+	}
+}
+
+func TestCastRefMatchHash(t *testing.T) {
+	hash := []byte{0xcd, 0x31, 0x41, 0xa4, 0x7b}
+	if !(CastRef{Hash: "0xcd3141a4"}).MatchHash(hash) {
+		t.Error("expected prefix to match")
+	}
+	if (CastRef{Hash: "0xcd3141a5"}).MatchHash(hash) {
+		t.Error("expected prefix not to match")
+	}
+	if !(CastRef{Hash: "0xcd3141a4"}).IsShort() {
+		t.Error("expected short hash")
+	}
+}
@@ -0,0 +1,44 @@
+package lemon3libs
+
+// maxRevisions is how many previous revisions Latest follows back from a cast.
+const maxRevisions = 100
+
+/*
+Revisions drops the casts of metadata that was replaced by a newer
+revision (see "lemon3 edit"). Casts must be checked newest first: each
+revision marks the metadata it replaces, and the metadata that one
+replaced, and so on, even if the casts of the revisions in between were
+removed.
+*/
+type Revisions struct {
+	replaced map[string]bool
+	previous func(cid string) string // the metadata cid replaces, or ""
+}
+
+// NewRevisions returns Revisions that fetch the older revisions with FromCid.
+func NewRevisions() *Revisions {
+	return &Revisions{
+		replaced: map[string]bool{},
+		previous: func(cid string) string {
+			meta, err := FromCid(cid)
+			if err != nil {
+				return ""
+			}
+			return meta.Previous["/"]
+		},
+	}
+}
+
+// Latest checks if c is the latest revision of its file, among the casts seen so far.
+func (r *Revisions) Latest(c *L3Cast) bool {
+	replaced := r.replaced[c.Lemon3Cid]
+	if c.Lemon3Data == nil {
+		return !replaced
+	}
+	previous := c.Lemon3Data.Previous["/"]
+	for i := 0; previous != "" && !r.replaced[previous] && i < maxRevisions; i++ {
+		r.replaced[previous] = true
+		previous = r.previous(previous)
+	}
+	return !replaced
+}
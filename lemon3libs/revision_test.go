@@ -0,0 +1,36 @@
+package lemon3libs
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRevisions(t *testing.T) {
+	cast := func(cid, previous string) *L3Cast {
+		meta := &Lemon3Metadata{}
+		if previous != "" {
+			meta.Previous = map[string]string{"/": previous}
+		}
+		return &L3Cast{Lemon3Cid: cid, Lemon3Data: meta}
+	}
+	stored := map[string]string{"c3": "c2", "c2": "c1", "v3": "v2", "v2": "v1"}
+	latest := func(casts ...*L3Cast) []string {
+		r := &Revisions{replaced: map[string]bool{}, previous: func(cid string) string { return stored[cid] }}
+		found := []string{}
+		for _, c := range casts {
+			if r.Latest(c) {
+				found = append(found, c.Lemon3Cid)
+			}
+		}
+		return found
+	}
+
+	// Newest first: c3 replaces c2, which replaced c1. x is unrelated.
+	if got := latest(cast("c3", "c2"), cast("x", ""), cast("c2", "c1"), cast("c1", "")); !slices.Equal(got, []string{"c3", "x"}) {
+		t.Errorf("latest = %v, expected [c3 x]", got)
+	}
+	// The cast of v2 was removed, v1 is still replaced by v3.
+	if got := latest(cast("v3", "v2"), cast("v1", "")); !slices.Equal(got, []string{"v3"}) {
+		t.Errorf("latest = %v, expected [v3]", got)
+	}
+}
@@ -0,0 +1,106 @@
+package lemon3libs
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	pb "github.com/vrypan/farcaster-go/farcaster"
+)
+
+// Sidecar is the content of the .lemon3.json file saved next to a download.
+type Sidecar struct {
+	Cid      string          `json:"cid"` // lemon3 metadata CID
+	Cast     *L3Cast         `json:"cast,omitempty"`
+	Metadata *Lemon3Metadata `json:"metadata"`
+}
+
+func (s Sidecar) ToJSON() ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// NewL3Cast returns the L3Cast of a cast whose metadata is already known.
+func NewL3Cast(msg *pb.Message, meta *Lemon3Metadata) *L3Cast {
+	return &L3Cast{
+		Fid:        msg.Data.Fid,
+		Timestamp:  uint64(msg.Data.Timestamp) + uint64(FARCASTER_EPOCH),
+		Hash:       fmt.Sprintf("0x%x", msg.Hash),
+		Lemon3Cid:  CidFromMessage(msg),
+		Text:       msg.Data.GetCastAddBody().Text,
+		Lemon3Data: meta,
+	}
+}
+
+/*
+SafeFilename returns the metadata filename reduced to a plain file name,
+so that it can not be used to write outside the download directory.
+*/
+func SafeFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == ".." || name == "/" || name == "" {
+		return "download"
+	}
+	return name
+}
+
+type nfoUniqueId struct {
+	Type    string `xml:"type,attr"`
+	Default bool   `xml:"default,attr"`
+	Value   string `xml:",chardata"`
+}
+
+type nfoThumb struct {
+	Aspect string `xml:"aspect,attr"`
+	Value  string `xml:",chardata"`
+}
+
+// nfoMovie is the subset of the Kodi movie .nfo format Kodi and Jellyfin use for standalone videos.
+type nfoMovie struct {
+	XMLName   xml.Name    `xml:"movie"`
+	Title     string      `xml:"title"`
+	Plot      string      `xml:"plot,omitempty"`
+	Premiered string      `xml:"premiered,omitempty"`
+	DateAdded string      `xml:"dateadded,omitempty"`
+	Credits   string      `xml:"credits,omitempty"`
+	Thumb     *nfoThumb   `xml:"thumb,omitempty"`
+	UniqueId  nfoUniqueId `xml:"uniqueid"`
+	Tag       string      `xml:"tag"`
+}
+
+/*
+Nfo returns a Kodi/Jellyfin .nfo document for a video download. poster is
+the file name of the saved artwork, or "". cast can be nil.
+
+Other files get none: Kodi reads music .nfo files per album and artist,
+not per track.
+*/
+func Nfo(cid string, cast *L3Cast, meta *Lemon3Metadata, poster string) ([]byte, error) {
+	if !strings.HasPrefix(meta.Type, "video/") {
+		return nil, fmt.Errorf(".nfo files are only written for videos, not %s", meta.Type)
+	}
+	movie := nfoMovie{
+		Title:    meta.Title,
+		Plot:     meta.Description,
+		UniqueId: nfoUniqueId{Type: "lemon3", Default: true, Value: cid},
+		Tag:      "lemon3",
+	}
+	if cast != nil {
+		date := time.Unix(int64(cast.Timestamp), 0).UTC()
+		movie.Premiered = date.Format("2006-01-02")
+		movie.DateAdded = date.Format("2006-01-02 15:04:05")
+		if cast.Fname != "" {
+			movie.Credits = "@" + cast.Fname
+		}
+	}
+	if poster != "" {
+		movie.Thumb = &nfoThumb{Aspect: "poster", Value: poster}
+	}
+	data, err := xml.MarshalIndent(movie, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
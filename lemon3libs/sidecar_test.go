@@ -0,0 +1,50 @@
+package lemon3libs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSafeFilename(t *testing.T) {
+	tests := map[string]string{
+		"movie.mp4":          "movie.mp4",
+		"../../.bashrc":      ".bashrc",
+		"/etc/passwd":        "passwd",
+		"..\\..\\evil.exe":   "evil.exe",
+		"..":                 "download",
+		"":                   "download",
+		"dir/sub/track.flac": "track.flac",
+	}
+	for input, expected := range tests {
+		if got := SafeFilename(input); got != expected {
+			t.Errorf("SafeFilename(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
+func TestNfo(t *testing.T) {
+	meta := &Lemon3Metadata{Title: "Plan 9 <from> Outer Space", Description: "A film", Type: "video/mp4"}
+	cast := &L3Cast{Fname: "fc1", Timestamp: 1740939060}
+	data, err := Nfo("bafycid", cast, meta, "plan9-poster.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nfo := string(data)
+	for _, s := range []string{
+		"<movie>",
+		"<title>Plan 9 &lt;from&gt; Outer Space</title>",
+		"<premiered>2025-03-02</premiered>",
+		"<credits>@fc1</credits>",
+		`<thumb aspect="poster">plan9-poster.jpg</thumb>`,
+		`<uniqueid type="lemon3" default="true">bafycid</uniqueid>`,
+	} {
+		if !strings.Contains(nfo, s) {
+			t.Errorf("expected %s in:\n%s", s, nfo)
+		}
+	}
+
+	meta.Type = "audio/mpeg"
+	if _, err := Nfo("bafycid", cast, meta, ""); err == nil {
+		t.Error("expected error for audio")
+	}
+}
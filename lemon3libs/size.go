@@ -0,0 +1,42 @@
+package lemon3libs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HumanSize formats a size in bytes using binary prefixes.
+func HumanSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+/*
+ParseSize parses a size like "1500", "10K", "2.5MB" or "1GiB".
+Prefixes are binary: 1K = 1KB = 1KiB = 1024 bytes.
+*/
+func ParseSize(s string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	str = strings.TrimSuffix(strings.TrimSuffix(str, "B"), "I")
+	multiplier := int64(1)
+	if n := len(str); n > 0 {
+		if i := strings.IndexByte("KMGTPE", str[n-1]); i >= 0 {
+			multiplier = int64(1) << (10 * (i + 1))
+			str = str[:n-1]
+		}
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(value * float64(multiplier)), nil
+}
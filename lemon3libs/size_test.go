@@ -0,0 +1,39 @@
+package lemon3libs
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{
+		"0":      0,
+		"1500":   1500,
+		"1500B":  1500,
+		"10K":    10 * 1024,
+		"2.5MB":  2.5 * 1024 * 1024,
+		"1GiB":   1 << 30,
+		" 3 mb ": 3 << 20,
+	}
+	for input, expected := range tests {
+		got, err := ParseSize(input)
+		if err != nil {
+			t.Errorf("ParseSize(%q): %v", input, err)
+			continue
+		}
+		if got != expected {
+			t.Errorf("ParseSize(%q) = %d, expected %d", input, got, expected)
+		}
+	}
+	for _, input := range []string{"", "MB", "-1K", "ten"} {
+		if _, err := ParseSize(input); err == nil {
+			t.Errorf("ParseSize(%q): expected error", input)
+		}
+	}
+}
+
+func TestHumanSize(t *testing.T) {
+	if got := HumanSize(512); got != "512 B" {
+		t.Errorf("HumanSize(512) = %q", got)
+	}
+	if got := HumanSize(3 << 20); got != "3.0 MiB" {
+		t.Errorf("HumanSize(3MiB) = %q", got)
+	}
+}
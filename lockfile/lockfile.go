@@ -0,0 +1,36 @@
+/*
+Package lockfile keeps lemon3 processes from changing the same file at
+the same time, like "lemon3 daemon" and "lemon3 upload".
+*/
+package lockfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Lock creates the lock file at path, waiting up to 10 seconds if it exists, and returns the function that removes it.
+func Lock(path string) (func(), error) {
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		// A lock older than a minute was left behind by a process that did not exit cleanly.
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > time.Minute {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("locked by another lemon3 process (%s)", path)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,114 @@
+package mediainfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	exifImageDescription = 0x010e
+	exifArtist           = 0x013b
+	exifXPTitle          = 0x9c9b
+	exifXPComment        = 0x9c9c
+	exifXPAuthor         = 0x9c9d
+)
+
+// readJPEG reads the EXIF metadata in the APP1 segment of a JPEG file.
+func readJPEG(r io.Reader) (*Info, error) {
+	info := &Info{Mime: "image/jpeg"}
+	if _, err := io.ReadFull(r, make([]byte, 2)); err != nil { // SOI
+		return nil, err
+	}
+	for {
+		marker := make([]byte, 4)
+		if _, err := io.ReadFull(r, marker); err != nil || marker[0] != 0xff {
+			return info, nil
+		}
+		if marker[1] == 0xda || marker[1] == 0xd9 { // start of scan, end of image
+			return info, nil
+		}
+		n := int(binary.BigEndian.Uint16(marker[2:])) - 2
+		if n < 0 {
+			return info, nil
+		}
+		segment := make([]byte, n)
+		if _, err := io.ReadFull(r, segment); err != nil {
+			return info, nil
+		}
+		if marker[1] == 0xe1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			readExif(segment[6:], info)
+			return info, nil
+		}
+	}
+}
+
+// readExif reads the title, description and artist from the first IFD of TIFF data.
+func readExif(tiff []byte, info *Info) {
+	if len(tiff) < 8 {
+		return
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	values := map[uint16][]byte{}
+	for i := range count {
+		entry := ifd + 2 + 12*i
+		if entry+12 > len(tiff) {
+			break
+		}
+		tag := order.Uint16(tiff[entry:])
+		kind := order.Uint16(tiff[entry+2:])
+		n := int(order.Uint32(tiff[entry+4:]))
+		if kind != 1 && kind != 2 && kind != 7 { // BYTE, ASCII and UNDEFINED, 1 byte per value
+			continue
+		}
+		if n <= 4 {
+			values[tag] = tiff[entry+8 : entry+8+n]
+			continue
+		}
+		offset := int(order.Uint32(tiff[entry+8:]))
+		if offset < 0 || offset+n > len(tiff) {
+			continue
+		}
+		values[tag] = tiff[offset : offset+n]
+	}
+
+	ascii := func(tag uint16) string {
+		return strings.TrimSpace(strings.TrimRight(string(values[tag]), "\x00"))
+	}
+	xp := func(tag uint16) string { // UTF-16LE, used by Windows
+		b := values[tag]
+		units := make([]uint16, len(b)/2)
+		for i := range units {
+			units[i] = binary.LittleEndian.Uint16(b[2*i:])
+		}
+		return strings.TrimSpace(strings.TrimRight(string(utf16.Decode(units)), "\x00"))
+	}
+
+	description := ascii(exifImageDescription)
+	info.Title = xp(exifXPTitle)
+	info.Description = xp(exifXPComment)
+	if info.Title == "" {
+		info.Title = description
+	} else if info.Description == "" {
+		info.Description = description
+	}
+	info.Artist = ascii(exifArtist)
+	if info.Artist == "" {
+		info.Artist = xp(exifXPAuthor)
+	}
+}
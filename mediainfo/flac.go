@@ -0,0 +1,151 @@
+package mediainfo
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	flacStreamInfo    = 0
+	flacVorbisComment = 4
+	flacPicture       = 6
+)
+
+// maxBlockLen limits the metadata read into memory, pictures included.
+const maxBlockLen = 16 << 20
+
+func readFLAC(r io.ReadSeeker) (*Info, error) {
+	if _, err := r.Seek(4, io.SeekStart); err != nil { // "fLaC"
+		return nil, err
+	}
+	info := &Info{Mime: "audio/flac"}
+	pictureType := -1
+	for {
+		header := make([]byte, 4)
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil, fmt.Errorf("truncated FLAC metadata: %w", err)
+		}
+		last := header[0]&0x80 != 0
+		kind := header[0] & 0x7f
+		n := int(header[1])<<16 | int(header[2])<<8 | int(header[3])
+
+		switch kind {
+		case flacStreamInfo, flacVorbisComment, flacPicture:
+			if n > maxBlockLen {
+				return nil, fmt.Errorf("FLAC metadata block of %d bytes is too large", n)
+			}
+			block := make([]byte, n)
+			if _, err := io.ReadFull(r, block); err != nil {
+				return nil, fmt.Errorf("truncated FLAC metadata: %w", err)
+			}
+			switch kind {
+			case flacStreamInfo:
+				info.Duration = flacDuration(block)
+			case flacVorbisComment:
+				readVorbisComments(block, info)
+			case flacPicture:
+				if picType, mime, data := flacPictureBlock(block); data != nil && (pictureType == -1 || (picType == 3 && pictureType != 3)) {
+					pictureType = picType
+					info.Artwork = data
+					info.ArtworkMime = mime
+				}
+			}
+		default:
+			if _, err := r.Seek(int64(n), io.SeekCurrent); err != nil {
+				return nil, err
+			}
+		}
+		if last {
+			return info, nil
+		}
+	}
+}
+
+// flacDuration reads the sample rate and number of samples from a STREAMINFO block.
+func flacDuration(block []byte) time.Duration {
+	if len(block) < 18 {
+		return 0
+	}
+	// 20 bits sample rate, 3 bits channels, 5 bits bits per sample, 36 bits total samples
+	v := binary.BigEndian.Uint64(block[10:18])
+	sampleRate := v >> 44
+	samples := v & (1<<36 - 1)
+	if sampleRate == 0 {
+		return 0
+	}
+	return time.Duration(samples) * time.Second / time.Duration(sampleRate)
+}
+
+// readVorbisComments reads the TITLE, DESCRIPTION (or COMMENT) and ARTIST comments.
+func readVorbisComments(block []byte, info *Info) {
+	next := func() (string, bool) { // little-endian length-prefixed string
+		if len(block) < 4 {
+			return "", false
+		}
+		n := int(binary.LittleEndian.Uint32(block))
+		if n > len(block)-4 {
+			return "", false
+		}
+		s := string(block[4 : 4+n])
+		block = block[4+n:]
+		return s, true
+	}
+	if _, ok := next(); !ok { // vendor
+		return
+	}
+	if len(block) < 4 {
+		return
+	}
+	count := int(binary.LittleEndian.Uint32(block))
+	block = block[4:]
+	for range count {
+		comment, ok := next()
+		if !ok {
+			return
+		}
+		key, value, _ := strings.Cut(comment, "=")
+		switch strings.ToUpper(key) {
+		case "TITLE":
+			info.Title = value
+		case "ARTIST":
+			info.Artist = value
+		case "DESCRIPTION":
+			info.Description = value
+		case "COMMENT":
+			if info.Description == "" {
+				info.Description = value
+			}
+		}
+	}
+}
+
+// flacPictureBlock returns the picture type, MIME type and data of a PICTURE block.
+func flacPictureBlock(block []byte) (int, string, []byte) {
+	next := func() []byte { // big-endian length-prefixed bytes
+		if len(block) < 4 {
+			return nil
+		}
+		n := int(binary.BigEndian.Uint32(block))
+		if n > len(block)-4 {
+			return nil
+		}
+		b := block[4 : 4+n]
+		block = block[4+n:]
+		return b
+	}
+	if len(block) < 4 {
+		return 0, "", nil
+	}
+	kind := int(binary.BigEndian.Uint32(block))
+	block = block[4:]
+	mime := string(next())
+	next() // description
+	if len(block) < 16 {
+		return 0, "", nil
+	}
+	block = block[16:] // width, height, depth, colors
+	return kind, mime, next()
+}
@@ -0,0 +1,65 @@
+/*
+Package mediainfo extracts the title, description, cover art and duration
+of media files: MP3 (ID3v2), FLAC (Vorbis comments), MP4/M4A (iTunes
+atoms) and JPEG (EXIF).
+
+Only the parts of the file that hold metadata are read.
+*/
+package mediainfo
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"time"
+)
+
+// Info is the metadata found in a file. Fields that were not found are empty.
+type Info struct {
+	Mime        string // detected from the file format
+	Title       string
+	Description string
+	Artist      string
+	Artwork     []byte
+	ArtworkMime string
+	Duration    time.Duration
+}
+
+/*
+Extract reads the metadata of the file at path. Files of unknown formats
+return an empty Info, not an error.
+*/
+func Extract(path string) (*Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, 12)
+	n, _ := io.ReadFull(f, head)
+	head = head[:n]
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(head, []byte("ID3")) || isFrameSync(head):
+		return readMP3(f, stat.Size())
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		return readFLAC(f)
+	case len(head) >= 8 && string(head[4:8]) == "ftyp":
+		return readMP4(f, stat.Size())
+	case bytes.HasPrefix(head, []byte{0xff, 0xd8, 0xff}):
+		return readJPEG(f)
+	}
+	return &Info{}, nil
+}
+
+func isFrameSync(b []byte) bool {
+	return len(b) >= 2 && b[0] == 0xff && b[1]&0xe0 == 0xe0
+}
@@ -0,0 +1,192 @@
+package mediainfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vrypan/lemon3/id3"
+)
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func extract(t *testing.T, path string) *Info {
+	t.Helper()
+	info, err := Extract(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
+
+// MPEG1 layer 3, 128 kbps, 44.1 kHz, joint stereo
+var mp3Header = []byte{0xff, 0xfb, 0x90, 0x64}
+
+func TestMP3(t *testing.T) {
+	tag := id3.Tag{Title: "Episode 1", Comment: "Notes", Picture: []byte("jpeg"), PictureMime: "image/jpeg"}.Bytes()
+	audio := make([]byte, 16000) // one second at 128 kbps
+	copy(audio, mp3Header)
+	info := extract(t, writeFile(t, "a.mp3", append(tag, audio...)))
+
+	if info.Mime != "audio/mpeg" || info.Title != "Episode 1" || info.Description != "Notes" || string(info.Artwork) != "jpeg" {
+		t.Errorf("unexpected info %+v", info)
+	}
+	if info.Duration != time.Second {
+		t.Errorf("duration = %s, expected 1s", info.Duration)
+	}
+}
+
+func TestMP3Xing(t *testing.T) {
+	frame := make([]byte, 417)
+	copy(frame, mp3Header)
+	copy(frame[36:], "Xing")
+	binary.BigEndian.PutUint32(frame[40:], 1) // frames field present
+	binary.BigEndian.PutUint32(frame[44:], 441)
+	info := extract(t, writeFile(t, "a.mp3", frame))
+
+	expected := 441 * 1152 * time.Second / 44100
+	if info.Duration != expected {
+		t.Errorf("duration = %s, expected %s", info.Duration, expected)
+	}
+}
+
+func flacBlock(kind byte, last bool, data []byte) []byte {
+	if last {
+		kind |= 0x80
+	}
+	n := len(data)
+	return append([]byte{kind, byte(n >> 16), byte(n >> 8), byte(n)}, data...)
+}
+
+func TestFLAC(t *testing.T) {
+	streamInfo := make([]byte, 34)
+	// 44100 Hz, 2 channels, 16 bits, 441000 samples
+	binary.BigEndian.PutUint64(streamInfo[10:], 44100<<44|1<<41|15<<36|441000)
+
+	var comments bytes.Buffer
+	le := func(s string) {
+		binary.Write(&comments, binary.LittleEndian, uint32(len(s)))
+		comments.WriteString(s)
+	}
+	le("vendor")
+	binary.Write(&comments, binary.LittleEndian, uint32(3))
+	le("TITLE=Song")
+	le("artist=Band")
+	le("COMMENT=Live")
+
+	var picture bytes.Buffer
+	be := func(b []byte) {
+		binary.Write(&picture, binary.BigEndian, uint32(len(b)))
+		picture.Write(b)
+	}
+	binary.Write(&picture, binary.BigEndian, uint32(3))
+	be([]byte("image/png"))
+	be(nil)
+	picture.Write(make([]byte, 16))
+	be([]byte("png"))
+
+	data := []byte("fLaC")
+	data = append(data, flacBlock(flacStreamInfo, false, streamInfo)...)
+	data = append(data, flacBlock(1, false, make([]byte, 10))...) // padding
+	data = append(data, flacBlock(flacVorbisComment, false, comments.Bytes())...)
+	data = append(data, flacBlock(flacPicture, true, picture.Bytes())...)
+	info := extract(t, writeFile(t, "a.flac", data))
+
+	if info.Mime != "audio/flac" || info.Title != "Song" || info.Artist != "Band" || info.Description != "Live" {
+		t.Errorf("unexpected info %+v", info)
+	}
+	if string(info.Artwork) != "png" || info.ArtworkMime != "image/png" {
+		t.Errorf("unexpected artwork %q (%s)", info.Artwork, info.ArtworkMime)
+	}
+	if info.Duration != 10*time.Second {
+		t.Errorf("duration = %s, expected 10s", info.Duration)
+	}
+}
+
+func mp4Box(kind string, children ...[]byte) []byte {
+	body := bytes.Join(children, nil)
+	b := make([]byte, 8, 8+len(body))
+	binary.BigEndian.PutUint32(b, uint32(8+len(body)))
+	copy(b[4:], kind)
+	return append(b, body...)
+}
+
+func mp4Data(dataType uint32, value string) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint32(b, dataType)
+	return mp4Box("data", b, []byte(value))
+}
+
+func TestMP4(t *testing.T) {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000) // timescale
+	binary.BigEndian.PutUint32(mvhd[16:], 5500) // duration
+	data := append(
+		mp4Box("ftyp", []byte("M4A \x00\x00\x00\x00")),
+		mp4Box("moov",
+			mp4Box("mvhd", mvhd),
+			mp4Box("udta",
+				mp4Box("meta", make([]byte, 4),
+					mp4Box("hdlr", make([]byte, 25)),
+					mp4Box("ilst",
+						mp4Box("\xa9nam", mp4Data(1, "Track")),
+						mp4Box("\xa9cmt", mp4Data(1, "Comment")),
+						mp4Box("covr", mp4Data(14, "png")),
+					),
+				),
+			),
+		)...,
+	)
+	data = append(data, mp4Box("mdat", make([]byte, 32))...)
+	info := extract(t, writeFile(t, "a.m4a", data))
+
+	if info.Mime != "audio/mp4" || info.Title != "Track" || info.Description != "Comment" {
+		t.Errorf("unexpected info %+v", info)
+	}
+	if string(info.Artwork) != "png" || info.ArtworkMime != "image/png" {
+		t.Errorf("unexpected artwork %q (%s)", info.Artwork, info.ArtworkMime)
+	}
+	if info.Duration != 5500*time.Millisecond {
+		t.Errorf("duration = %s, expected 5.5s", info.Duration)
+	}
+}
+
+func TestJPEG(t *testing.T) {
+	description := "A lemon on a table"
+	tiff := []byte("II*\x00\x08\x00\x00\x00")
+	tiff = binary.LittleEndian.AppendUint16(tiff, 1) // one entry
+	tiff = binary.LittleEndian.AppendUint16(tiff, exifImageDescription)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 2) // ASCII
+	tiff = binary.LittleEndian.AppendUint32(tiff, uint32(len(description)+1))
+	tiff = binary.LittleEndian.AppendUint32(tiff, 8+2+12+4) // after the IFD
+	tiff = binary.LittleEndian.AppendUint32(tiff, 0)        // no next IFD
+	tiff = append(tiff, description+"\x00"...)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	data := []byte{0xff, 0xd8, 0xff, 0xe1}
+	data = binary.BigEndian.AppendUint16(data, uint16(len(segment)+2))
+	data = append(data, segment...)
+	data = append(data, 0xff, 0xda, 0, 2)
+	info := extract(t, writeFile(t, "a.jpg", data))
+
+	if info.Mime != "image/jpeg" || info.Title != description {
+		t.Errorf("unexpected info %+v", info)
+	}
+}
+
+func TestUnknown(t *testing.T) {
+	info := extract(t, writeFile(t, "a.txt", []byte("hello")))
+	if info.Mime != "" || info.Title != "" || info.Duration != 0 {
+		t.Errorf("expected empty info, got %+v", info)
+	}
+}
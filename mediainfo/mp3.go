@@ -0,0 +1,145 @@
+package mediainfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"time"
+
+	"github.com/vrypan/lemon3/id3"
+)
+
+var mp3Bitrates = map[[2]int][16]int{ // [MPEG1?, layer] -> kbps
+	{1, 1}: {0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+	{1, 2}: {0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+	{1, 3}: {0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	{0, 1}: {0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+	{0, 2}: {0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	{0, 3}: {0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+}
+
+var mp3SampleRates = [3]int{44100, 48000, 32000}
+
+// mp3Frame is the header of an MPEG audio frame.
+type mp3Frame struct {
+	mpeg1      bool
+	layer      int
+	bitrate    int // bits per second
+	sampleRate int
+	mono       bool
+}
+
+func parseFrameHeader(b []byte) (mp3Frame, bool) {
+	if len(b) < 4 || !isFrameSync(b) {
+		return mp3Frame{}, false
+	}
+	version := (b[1] >> 3) & 3 // 0: MPEG2.5, 2: MPEG2, 3: MPEG1
+	layer := 4 - int((b[1]>>1)&3)
+	bitrateIndex := int(b[2] >> 4)
+	rateIndex := int((b[2] >> 2) & 3)
+	if version == 1 || layer == 4 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return mp3Frame{}, false
+	}
+	f := mp3Frame{mpeg1: version == 3, layer: layer, mono: b[3]>>6 == 3}
+	mpeg1 := 0
+	if f.mpeg1 {
+		mpeg1 = 1
+	}
+	f.bitrate = mp3Bitrates[[2]int{mpeg1, layer}][bitrateIndex] * 1000
+	f.sampleRate = mp3SampleRates[rateIndex]
+	switch version {
+	case 2:
+		f.sampleRate /= 2
+	case 0:
+		f.sampleRate /= 4
+	}
+	return f, true
+}
+
+func (f mp3Frame) samplesPerFrame() int {
+	switch {
+	case f.layer == 1:
+		return 384
+	case f.layer == 3 && !f.mpeg1:
+		return 576
+	}
+	return 1152
+}
+
+// vbrFrames returns the number of frames from a Xing/Info or VBRI header in the first frame, or 0.
+func (f mp3Frame) vbrFrames(frame []byte) int {
+	sideInfo := 32
+	switch {
+	case f.mpeg1 && f.mono, !f.mpeg1 && !f.mono:
+		sideInfo = 17
+	case !f.mpeg1 && f.mono:
+		sideInfo = 9
+	}
+	if x := 4 + sideInfo; len(frame) >= x+12 {
+		tag := string(frame[x : x+4])
+		if (tag == "Xing" || tag == "Info") && binary.BigEndian.Uint32(frame[x+4:])&1 != 0 {
+			return int(binary.BigEndian.Uint32(frame[x+8:]))
+		}
+	}
+	if len(frame) >= 36+18 && string(frame[36:40]) == "VBRI" {
+		return int(binary.BigEndian.Uint32(frame[36+14:]))
+	}
+	return 0
+}
+
+func readMP3(f *os.File, size int64) (*Info, error) {
+	tag, tagLen, err := id3.Read(f)
+	if err != nil {
+		return nil, err
+	}
+	info := &Info{
+		Mime:        "audio/mpeg",
+		Title:       tag.Title,
+		Description: tag.Comment,
+		Artist:      tag.Artist,
+		Artwork:     tag.Picture,
+		ArtworkMime: tag.PictureMime,
+		Duration:    tag.Length,
+	}
+	if info.Duration > 0 {
+		return info, nil
+	}
+
+	// Find the first frame, allowing for some junk after the tag.
+	buf := make([]byte, 64*1024)
+	if _, err := f.Seek(int64(tagLen), io.SeekStart); err != nil {
+		return nil, err
+	}
+	n, _ := io.ReadFull(f, buf)
+	buf = buf[:n]
+	for i := 0; i+4 <= len(buf); i++ {
+		frame, ok := parseFrameHeader(buf[i:])
+		if !ok {
+			continue
+		}
+		if frames := frame.vbrFrames(buf[i:]); frames > 0 {
+			info.Duration = time.Duration(frames) * time.Duration(frame.samplesPerFrame()) * time.Second / time.Duration(frame.sampleRate)
+			return info, nil
+		}
+		// Constant bitrate: the duration follows from the size of the audio data.
+		audio := size - int64(tagLen) - int64(i)
+		if hasID3v1(f, size) {
+			audio -= 128
+		}
+		info.Duration = time.Duration(float64(audio*8) / float64(frame.bitrate) * float64(time.Second))
+		return info, nil
+	}
+	return info, nil
+}
+
+func hasID3v1(f *os.File, size int64) bool {
+	if size < 128 {
+		return false
+	}
+	b := make([]byte, 3)
+	if _, err := f.ReadAt(b, size-128); err != nil {
+		return false
+	}
+	return bytes.Equal(b, []byte("TAG"))
+}
@@ -0,0 +1,169 @@
+package mediainfo
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// maxMoovLen limits the size of the moov box read into memory.
+const maxMoovLen = 64 << 20
+
+type box struct {
+	kind string
+	data []byte
+}
+
+// boxes splits b into the MP4 boxes it contains.
+func boxes(b []byte) []box {
+	list := []box{}
+	for len(b) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(b))
+		kind := string(b[4:8])
+		header := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(b))
+		case 1:
+			if len(b) < 16 {
+				return list
+			}
+			size = binary.BigEndian.Uint64(b[8:])
+			header = 16
+		}
+		if size < header || size > uint64(len(b)) {
+			return list
+		}
+		list = append(list, box{kind, b[header:size]})
+		b = b[size:]
+	}
+	return list
+}
+
+func child(b []byte, kind string) []byte {
+	for _, c := range boxes(b) {
+		if c.kind == kind {
+			return c.data
+		}
+	}
+	return nil
+}
+
+func readMP4(r io.ReadSeeker, size int64) (*Info, error) {
+	var brand string
+	var moov []byte
+	offset := int64(0)
+	for offset+8 <= size && moov == nil {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		header := make([]byte, 16)
+		if _, err := io.ReadFull(r, header[:8]); err != nil {
+			return nil, err
+		}
+		boxLen := int64(binary.BigEndian.Uint32(header))
+		kind := string(header[4:8])
+		headerLen := int64(8)
+		switch boxLen {
+		case 0:
+			boxLen = size - offset
+		case 1:
+			if _, err := io.ReadFull(r, header[8:16]); err != nil {
+				return nil, err
+			}
+			boxLen = int64(binary.BigEndian.Uint64(header[8:]))
+			headerLen = 16
+		}
+		if boxLen < headerLen {
+			return nil, fmt.Errorf("invalid MP4 box %q", kind)
+		}
+		switch kind {
+		case "ftyp":
+			b := make([]byte, 4)
+			if _, err := io.ReadFull(r, b); err == nil {
+				brand = string(b)
+			}
+		case "moov":
+			if boxLen-headerLen > maxMoovLen {
+				return nil, fmt.Errorf("MP4 moov box of %d bytes is too large", boxLen)
+			}
+			moov = make([]byte, boxLen-headerLen)
+			if _, err := io.ReadFull(r, moov); err != nil {
+				return nil, fmt.Errorf("truncated MP4 moov box: %w", err)
+			}
+		}
+		offset += boxLen
+	}
+
+	info := &Info{Mime: "video/mp4"}
+	switch {
+	case brand == "M4A " || brand == "M4B " || brand == "M4P ":
+		info.Mime = "audio/mp4"
+	case brand == "qt  ":
+		info.Mime = "video/quicktime"
+	case moov != nil && !hasVideoTrack(moov):
+		info.Mime = "audio/mp4"
+	}
+	if moov == nil {
+		return info, nil
+	}
+
+	if mvhd := child(moov, "mvhd"); len(mvhd) >= 20 {
+		var timescale, duration uint64
+		if mvhd[0] == 1 && len(mvhd) >= 32 {
+			timescale = uint64(binary.BigEndian.Uint32(mvhd[20:]))
+			duration = binary.BigEndian.Uint64(mvhd[24:])
+		} else {
+			timescale = uint64(binary.BigEndian.Uint32(mvhd[12:]))
+			duration = uint64(binary.BigEndian.Uint32(mvhd[16:]))
+		}
+		if timescale > 0 {
+			info.Duration = time.Duration(float64(duration) / float64(timescale) * float64(time.Second))
+		}
+	}
+
+	meta := child(child(moov, "udta"), "meta")
+	if len(meta) >= 12 && string(meta[4:8]) != "hdlr" {
+		meta = meta[4:] // version and flags, missing in some QuickTime files
+	}
+	for _, item := range boxes(child(meta, "ilst")) {
+		data := child(item.data, "data")
+		if len(data) < 8 {
+			continue
+		}
+		dataType, value := binary.BigEndian.Uint32(data)&0xffffff, data[8:]
+		switch item.kind {
+		case "\xa9nam":
+			info.Title = string(value)
+		case "\xa9ART":
+			info.Artist = string(value)
+		case "ldes":
+			info.Description = string(value)
+		case "desc", "\xa9cmt":
+			if info.Description == "" {
+				info.Description = string(value)
+			}
+		case "covr":
+			info.Artwork = value
+			info.ArtworkMime = "image/jpeg"
+			if dataType == 14 {
+				info.ArtworkMime = "image/png"
+			}
+		}
+	}
+	return info, nil
+}
+
+func hasVideoTrack(moov []byte) bool {
+	for _, b := range boxes(moov) {
+		if b.kind != "trak" {
+			continue
+		}
+		hdlr := child(child(b.data, "mdia"), "hdlr")
+		if len(hdlr) >= 12 && string(hdlr[8:12]) == "vide" {
+			return true
+		}
+	}
+	return false
+}
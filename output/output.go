@@ -0,0 +1,125 @@
+/*
+Package output prints the progress and results of lemon3 commands.
+
+In text mode, progress and status lines go to stderr and results to
+stdout. In json mode every message is a JSON object on its own line
+(NDJSON) on stdout, with a "type" of "progress", "status", "result"
+or "error".
+*/
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vrypan/lemon3/errs"
+)
+
+const (
+	Text = "text"
+	JSON = "json"
+)
+
+var (
+	format           = Text
+	stdout io.Writer = os.Stdout
+	stderr io.Writer = os.Stderr
+	mu     sync.Mutex
+)
+
+// SetFormat selects the output format, Text or JSON.
+func SetFormat(f string) error {
+	switch f {
+	case Text, JSON:
+		format = f
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q, use %s or %s", f, Text, JSON)
+}
+
+func IsJSON() bool {
+	return format == JSON
+}
+
+type Fields map[string]any
+
+func emit(kind string, fields Fields) {
+	obj := Fields{"type": kind, "time": time.Now().UTC().Format(time.RFC3339)}
+	maps.Copy(obj, fields)
+	b, err := json.Marshal(obj)
+	if err != nil {
+		b, _ = json.Marshal(Fields{"type": "error", "error": err.Error()})
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	stdout.Write(append(b, '\n'))
+}
+
+func message(format string, a ...any) string {
+	return strings.TrimSpace(fmt.Sprintf(format, a...))
+}
+
+/*
+Progress reports the progress of a long operation. In text mode the
+message overwrites the current line, so it should not end with a newline.
+*/
+func Progress(fields Fields, format string, a ...any) {
+	if IsJSON() {
+		emit("progress", fields)
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	fmt.Fprintf(stderr, "\r"+format, a...)
+}
+
+// EndProgress ends a progress line that was not followed by a status.
+func EndProgress() {
+	if IsJSON() {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	fmt.Fprintln(stderr)
+}
+
+// Status reports that a step of a command finished. event names the step.
+func Status(event string, fields Fields, format string, a ...any) {
+	if IsJSON() {
+		f := Fields{"event": event, "message": message(format, a...)}
+		maps.Copy(f, fields)
+		emit("status", f)
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	fmt.Fprintf(stderr, format+"\n", a...)
+}
+
+// Result prints the result of a command. Commands should print one result.
+func Result(fields Fields, format string, a ...any) {
+	if IsJSON() {
+		emit("result", fields)
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	fmt.Fprintf(stdout, format+"\n", a...)
+}
+
+// Error reports the error that made a command fail.
+func Error(err error) {
+	if IsJSON() {
+		emit("error", Fields{"error": err.Error(), "kind": errs.KindOf(err).String(), "exit_code": errs.ExitCode(err)})
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	fmt.Fprintf(stderr, "[!] %v\n", err)
+}
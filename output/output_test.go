@@ -0,0 +1,77 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func capture(t *testing.T, f string) (*bytes.Buffer, *bytes.Buffer) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	origOut, origErr := stdout, stderr
+	stdout, stderr = &out, &errOut
+	if err := SetFormat(f); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() {
+		stdout, stderr, format = origOut, origErr, Text
+	})
+	return &out, &errOut
+}
+
+func TestText(t *testing.T) {
+	out, errOut := capture(t, Text)
+	Progress(Fields{"percent": 50}, "[^] Uploading: %d%%", 50)
+	Status("pinned", Fields{"cid": "Qm"}, "\r[+] %s pinned.", "Qm")
+	Result(Fields{"hash": "0x1"}, "Cast posted: %s", "0x1")
+
+	if errOut.String() != "\r[^] Uploading: 50%\r[+] Qm pinned.\n" {
+		t.Fatalf("unexpected stderr %q", errOut.String())
+	}
+	if out.String() != "Cast posted: 0x1\n" {
+		t.Fatalf("unexpected stdout %q", out.String())
+	}
+}
+
+func TestJSON(t *testing.T) {
+	out, errOut := capture(t, JSON)
+	Progress(Fields{"percent": 50}, "[^] Uploading: %d%%", 50)
+	EndProgress()
+	Status("pinned", Fields{"cid": "Qm"}, "\r[+] %s pinned.", "Qm")
+	Result(Fields{"hash": "0x1"}, "Cast posted: %s", "0x1")
+	Error(errors.New("failed"))
+
+	if errOut.Len() != 0 {
+		t.Fatalf("unexpected stderr %q", errOut.String())
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	expected := []map[string]any{
+		{"type": "progress", "percent": 50.0},
+		{"type": "status", "event": "pinned", "cid": "Qm", "message": "[+] Qm pinned."},
+		{"type": "result", "hash": "0x1"},
+		{"type": "error", "error": "failed", "kind": "unknown", "exit_code": 1.0},
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d lines, got %d: %q", len(expected), len(lines), out.String())
+	}
+	for i, line := range lines {
+		var got map[string]any
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("line %d is not JSON: %v", i, err)
+		}
+		for k, v := range expected[i] {
+			if got[k] != v {
+				t.Fatalf("line %d: expected %s=%v, got %v", i, k, v, got[k])
+			}
+		}
+	}
+}
+
+func TestSetFormat(t *testing.T) {
+	if err := SetFormat("yaml"); err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+}
@@ -0,0 +1,233 @@
+/*
+Package pinning is a client for the IPFS Pinning Service API, the /pins
+REST API of remote pinning services:
+https://ipfs.github.io/pinning-services-api-spec/
+*/
+package pinning
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/vrypan/lemon3/errs"
+)
+
+// Statuses of a pin request.
+const (
+	Queued  = "queued"
+	Pinning = "pinning"
+	Pinned  = "pinned"
+	Failed  = "failed"
+)
+
+// Pin is the object a service is asked to pin.
+type Pin struct {
+	Cid     string            `json:"cid"`
+	Name    string            `json:"name,omitempty"`
+	Origins []string          `json:"origins,omitempty"` // multiaddrs of peers that have the content
+	Meta    map[string]string `json:"meta,omitempty"`
+}
+
+// PinStatus is the state of a pin request.
+type PinStatus struct {
+	RequestId string            `json:"requestid"`
+	Status    string            `json:"status"`
+	Created   time.Time         `json:"created"`
+	Pin       Pin               `json:"pin"`
+	Delegates []string          `json:"delegates"`
+	Info      map[string]string `json:"info,omitempty"`
+}
+
+// Service is a remote pinning service.
+type Service struct {
+	Name     string
+	Endpoint string // the URL /pins is appended to
+	Token    string // sent as a bearer token
+	Client   *http.Client
+}
+
+func (s *Service) do(ctx context.Context, method, path string, body any, result any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(s.Endpoint, "/")+path, reader)
+	if err != nil {
+		return errs.Wrap(errs.Config, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errs.Wrap(errs.Network, fmt.Errorf("%s: %w", s.Name, err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return s.responseError(resp)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return errs.Wrap(errs.Network, fmt.Errorf("%s: invalid response: %w", s.Name, err))
+	}
+	return nil
+}
+
+// responseError returns the error of a failed request, with the reason the service gives.
+func (s *Service) responseError(resp *http.Response) error {
+	var failure struct {
+		Error struct {
+			Reason  string `json:"reason"`
+			Details string `json:"details"`
+		} `json:"error"`
+	}
+	body, _ := io.ReadAll(resp.Body)
+	msg := resp.Status
+	if json.Unmarshal(body, &failure) == nil && failure.Error.Reason != "" {
+		msg = failure.Error.Reason
+		if failure.Error.Details != "" {
+			msg += ": " + failure.Error.Details
+		}
+	}
+	kind := errs.Network
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		kind = errs.Auth
+	case http.StatusNotFound:
+		kind = errs.NotFound
+	case http.StatusBadRequest:
+		kind = errs.UserInput
+	}
+	return errs.New(kind, "%s: %s", s.Name, msg)
+}
+
+// Add asks the service to pin pin.
+func (s *Service) Add(ctx context.Context, pin Pin) (*PinStatus, error) {
+	var status PinStatus
+	if err := s.do(ctx, "POST", "/pins", pin, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Get returns the status of the pin request with requestId.
+func (s *Service) Get(ctx context.Context, requestId string) (*PinStatus, error) {
+	var status PinStatus
+	if err := s.do(ctx, "GET", "/pins/"+url.PathEscape(requestId), nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Find returns the pin requests for cid that did not fail.
+func (s *Service) Find(ctx context.Context, cid string) ([]*PinStatus, error) {
+	var result struct {
+		Count   int          `json:"count"`
+		Results []*PinStatus `json:"results"`
+	}
+	query := url.Values{"cid": {cid}, "status": {Queued + "," + Pinning + "," + Pinned}}
+	if err := s.do(ctx, "GET", "/pins?"+query.Encode(), nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Results, nil
+}
+
+// Ensure asks the service to pin pin, unless it already has a request for its CID.
+func (s *Service) Ensure(ctx context.Context, pin Pin) (*PinStatus, error) {
+	existing, err := s.Find(ctx, pin.Cid)
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range existing {
+		if status.Pin.Cid == pin.Cid {
+			return status, nil
+		}
+	}
+	return s.Add(ctx, pin)
+}
+
+/*
+Wait polls the pin request with requestId every interval, until it is
+pinned, it fails, or ctx is done. progress is called with every status.
+*/
+func (s *Service) Wait(ctx context.Context, requestId string, interval time.Duration, progress func(*PinStatus)) (*PinStatus, error) {
+	for {
+		status, err := s.Get(ctx, requestId)
+		if err != nil {
+			return nil, err
+		}
+		if progress != nil {
+			progress(status)
+		}
+		switch status.Status {
+		case Pinned:
+			return status, nil
+		case Failed:
+			return status, errs.New(errs.Network, "%s failed to pin %s", s.Name, status.Pin.Cid)
+		}
+		select {
+		case <-ctx.Done():
+			return status, errs.New(errs.Network, "%s: %s is still %s: %v", s.Name, status.Pin.Cid, status.Status, ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
+
+/*
+PinAll asks every service to pin every pin, and waits until they are all
+pinned, one fails, or ctx is done. progress is called with every status.
+*/
+func PinAll(ctx context.Context, services []*Service, pins []Pin, interval time.Duration, progress func(*Service, *PinStatus)) error {
+	type request struct {
+		service *Service
+		status  *PinStatus
+	}
+	requests := []request{}
+	for _, s := range services {
+		for _, pin := range pins {
+			status, err := s.Ensure(ctx, pin)
+			if err != nil {
+				return err
+			}
+			if progress != nil {
+				progress(s, status)
+			}
+			requests = append(requests, request{s, status})
+		}
+	}
+	for _, r := range requests {
+		if r.status.Status == Pinned {
+			continue
+		}
+		s := r.service
+		_, err := s.Wait(ctx, r.status.RequestId, interval, func(status *PinStatus) {
+			if progress != nil {
+				progress(s, status)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
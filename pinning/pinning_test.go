@@ -0,0 +1,190 @@
+package pinning
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vrypan/lemon3/errs"
+)
+
+/*
+standIn is a pinning service that implements the parts of the spec lemon3
+uses. Requests go queued, pinning, then pinned (or failed, for CIDs that
+start with "fail") as they are polled.
+*/
+type standIn struct {
+	mu    sync.Mutex
+	token string
+	pins  map[string]*PinStatus
+	added int
+}
+
+func (s *standIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	fail := func(code int, reason string) {
+		w.WriteHeader(code)
+		fmt.Fprintf(w, `{"error":{"reason":%q}}`, reason)
+	}
+	if r.Header.Get("Authorization") != "Bearer "+s.token {
+		fail(http.StatusUnauthorized, "UNAUTHORIZED")
+		return
+	}
+	switch {
+	case r.Method == "POST" && r.URL.Path == "/pins":
+		var pin Pin
+		if err := json.NewDecoder(r.Body).Decode(&pin); err != nil || pin.Cid == "" {
+			fail(http.StatusBadRequest, "BAD_REQUEST")
+			return
+		}
+		s.added++
+		status := &PinStatus{RequestId: fmt.Sprintf("r%d", s.added), Status: Queued, Created: time.Now(), Pin: pin}
+		s.pins[status.RequestId] = status
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(status)
+	case r.Method == "GET" && r.URL.Path == "/pins":
+		results := []*PinStatus{}
+		for _, status := range s.pins {
+			if status.Pin.Cid == r.URL.Query().Get("cid") && strings.Contains(r.URL.Query().Get("status"), status.Status) {
+				results = append(results, status)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"count": len(results), "results": results})
+	case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/pins/"):
+		status, ok := s.pins[strings.TrimPrefix(r.URL.Path, "/pins/")]
+		if !ok {
+			fail(http.StatusNotFound, "NOT_FOUND")
+			return
+		}
+		json.NewEncoder(w).Encode(status)
+		switch {
+		case status.Status == Queued:
+			status.Status = Pinning
+		case status.Status == Pinning && strings.HasPrefix(status.Pin.Cid, "fail"):
+			status.Status = Failed
+		case status.Status == Pinning:
+			status.Status = Pinned
+		}
+	default:
+		fail(http.StatusNotFound, "NOT_FOUND")
+	}
+}
+
+func newStandIn(t *testing.T) (*standIn, *Service) {
+	s := &standIn{token: "secret", pins: map[string]*PinStatus{}}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	return s, &Service{Name: "stand-in", Endpoint: server.URL + "/", Token: "secret"}
+}
+
+func TestEnsureAndWait(t *testing.T) {
+	s, service := newStandIn(t)
+	ctx := context.Background()
+
+	status, err := service.Ensure(ctx, Pin{Cid: "bafyexample", Name: "ep01.mp3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != Queued || status.RequestId == "" {
+		t.Fatalf("unexpected status %+v", status)
+	}
+	seen := []string{}
+	status, err = service.Wait(ctx, status.RequestId, time.Millisecond, func(s *PinStatus) { seen = append(seen, s.Status) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != Pinned || strings.Join(seen, ",") != "queued,pinning,pinned" {
+		t.Errorf("got %s after %v", status.Status, seen)
+	}
+
+	// The CID is already pinned, so Ensure does not ask again.
+	if again, err := service.Ensure(ctx, Pin{Cid: "bafyexample"}); err != nil || again.RequestId != status.RequestId {
+		t.Errorf("Ensure = %+v, %v, expected request %s", again, err, status.RequestId)
+	}
+	if s.added != 1 {
+		t.Errorf("expected 1 pin request, got %d", s.added)
+	}
+}
+
+func TestPinAll(t *testing.T) {
+	s, service := newStandIn(t)
+	other, otherService := newStandIn(t)
+	cids := []string{"bafyenclosure", "bafyartwork", "bafythumbnail", "bafymetadata"}
+	pins := []Pin{}
+	for _, cid := range cids {
+		pins = append(pins, Pin{Cid: cid})
+	}
+	pinned := map[string]int{}
+	err := PinAll(context.Background(), []*Service{service, otherService}, pins, time.Millisecond, func(_ *Service, status *PinStatus) {
+		if status.Status == Pinned {
+			pinned[status.Pin.Cid]++
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, standIn := range []*standIn{s, other} {
+		requested := map[string]bool{}
+		for _, status := range standIn.pins {
+			requested[status.Pin.Cid] = true
+		}
+		for _, cid := range cids {
+			if !requested[cid] {
+				t.Errorf("%s was not requested", cid)
+			}
+		}
+	}
+	for _, cid := range cids {
+		if pinned[cid] != 2 {
+			t.Errorf("%s pinned on %d services, expected 2", cid, pinned[cid])
+		}
+	}
+}
+
+func TestWaitFailed(t *testing.T) {
+	_, service := newStandIn(t)
+	ctx := context.Background()
+	status, err := service.Add(ctx, Pin{Cid: "failing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Wait(ctx, status.RequestId, time.Millisecond, nil); err == nil {
+		t.Error("expected an error for a failed pin")
+	}
+}
+
+func TestWaitTimeout(t *testing.T) {
+	_, service := newStandIn(t)
+	status, err := service.Add(context.Background(), Pin{Cid: "bafyslow"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := service.Wait(ctx, status.RequestId, time.Hour, nil); err == nil {
+		t.Error("expected an error when the pin is not done in time")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	_, service := newStandIn(t)
+	ctx := context.Background()
+
+	service.Token = "wrong"
+	_, err := service.Add(ctx, Pin{Cid: "bafyexample"})
+	if errs.KindOf(err) != errs.Auth || !strings.Contains(err.Error(), "UNAUTHORIZED") {
+		t.Errorf("wrong token: got %v (%s)", err, errs.KindOf(err))
+	}
+	service.Token = "secret"
+	if _, err := service.Get(ctx, "missing"); errs.KindOf(err) != errs.NotFound {
+		t.Errorf("unknown request: got %v (%s)", err, errs.KindOf(err))
+	}
+}
@@ -0,0 +1,218 @@
+/*
+Package pins is the registry of the CIDs lemon3 pinned on the local IPFS
+node, with what they are and the cast they were published with.
+*/
+package pins
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/vrypan/lemon3/lockfile"
+)
+
+// Roles of pinned CIDs.
+const (
+	Enclosure = "enclosure"
+	Artwork   = "artwork"
+	Thumbnail = "thumbnail"
+	Metadata  = "metadata"
+)
+
+// Cast is a cast that published a file.
+type Cast struct {
+	Ref string `json:"ref"` // @user/0xhash
+	Fid uint64 `json:"fid"` // FID of the cast, which unlike the fname can not change
+}
+
+/*
+Pin is a CID pinned for a lemon3 file. The enclosure, artwork, thumbnail
+and metadata of a file share the same Metadata CID, and the casts that
+published it. A CID used by more than one file has one Pin for each.
+*/
+type Pin struct {
+	Cid      string    `json:"cid"`
+	Role     string    `json:"role"`
+	Metadata string    `json:"metadata"`
+	Casts    []Cast    `json:"casts,omitempty"`
+	Profile  string    `json:"profile"`
+	Size     int64     `json:"size,omitempty"`
+	Pinned   time.Time `json:"pinned"`
+}
+
+// Registry is the list of pins, sorted by date.
+type Registry struct {
+	Pins []*Pin `json:"pins"`
+}
+
+// Load reads the registry at path. A missing file is an empty registry.
+func Load(path string) (*Registry, error) {
+	r := &Registry{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("invalid pin registry %s: %w", path, err)
+	}
+	return r, nil
+}
+
+func (r *Registry) save(path string) error {
+	sort.SliceStable(r.Pins, func(i, j int) bool { return r.Pins[i].Pinned.Before(r.Pins[j].Pinned) })
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Update loads the registry at path, calls fn, and saves the registry if fn returns nil.
+func Update(path string, fn func(r *Registry) error) error {
+	unlock, err := lockfile.Lock(path + ".lock")
+	if err != nil {
+		return fmt.Errorf("the pin registry is %w", err)
+	}
+	defer unlock()
+	r, err := Load(path)
+	if err != nil {
+		return err
+	}
+	if err := fn(r); err != nil {
+		return err
+	}
+	return r.save(path)
+}
+
+// Add adds p, or updates the pin with the same CID and metadata.
+func (r *Registry) Add(p Pin) {
+	if p.Pinned.IsZero() {
+		p.Pinned = time.Now()
+	}
+	for _, old := range r.Pins {
+		if old.Cid == p.Cid && old.Metadata == p.Metadata {
+			if len(p.Casts) == 0 {
+				p.Casts = old.Casts
+			}
+			if p.Size == 0 {
+				p.Size = old.Size
+			}
+			*old = p
+			return
+		}
+	}
+	r.Pins = append(r.Pins, &p)
+}
+
+/*
+Published adds a cast, posted by fid, to the pins of metadata. The same
+file can be cast more than once, with "cast --cid".
+*/
+func (r *Registry) Published(metadata string, cast string, fid uint64) {
+	c := Cast{Ref: cast, Fid: fid}
+	for _, p := range r.Pins {
+		if p.Metadata == metadata && !slices.Contains(p.Casts, c) {
+			p.Casts = append(p.Casts, c)
+		}
+	}
+}
+
+// Casts returns the casts that published the file with metadata.
+func (r *Registry) Casts(metadata string) []Cast {
+	casts := []Cast{}
+	for _, p := range r.Pins {
+		if p.Metadata == metadata {
+			for _, c := range p.Casts {
+				if !slices.Contains(casts, c) {
+					casts = append(casts, c)
+				}
+			}
+		}
+	}
+	return casts
+}
+
+/*
+Removed checks if every cast of the file with metadata was removed,
+asking removed about each cast. A file that was never cast is not
+removed.
+*/
+func (r *Registry) Removed(metadata string, removed func(c Cast) (bool, error)) (bool, error) {
+	casts := r.Casts(metadata)
+	for _, c := range casts {
+		gone, err := removed(c)
+		if err != nil || !gone {
+			return false, err
+		}
+	}
+	return len(casts) > 0, nil
+}
+
+// Remove deletes the pins of cid.
+func (r *Registry) Remove(cid string) {
+	pins := r.Pins[:0]
+	for _, p := range r.Pins {
+		if p.Cid != cid {
+			pins = append(pins, p)
+		}
+	}
+	r.Pins = pins
+}
+
+// RemoveMetadata deletes the pins of the file with metadata.
+func (r *Registry) RemoveMetadata(metadata string) {
+	pins := r.Pins[:0]
+	for _, p := range r.Pins {
+		if p.Metadata != metadata {
+			pins = append(pins, p)
+		}
+	}
+	r.Pins = pins
+}
+
+// Cids returns the pinned CIDs, each once, in the order of the registry.
+func (r *Registry) Cids() []string {
+	cids := []string{}
+	seen := map[string]bool{}
+	for _, p := range r.Pins {
+		if !seen[p.Cid] {
+			seen[p.Cid] = true
+			cids = append(cids, p.Cid)
+		}
+	}
+	return cids
+}
+
+/*
+Unused returns the CIDs that only the files in drop use, given by their
+metadata CID. The other CIDs of these files are still needed by another
+file, and must stay pinned.
+*/
+func (r *Registry) Unused(drop map[string]bool) []string {
+	needed := map[string]bool{}
+	for _, p := range r.Pins {
+		if !drop[p.Metadata] {
+			needed[p.Cid] = true
+		}
+	}
+	unused := []string{}
+	seen := map[string]bool{}
+	for _, p := range r.Pins {
+		if drop[p.Metadata] && !needed[p.Cid] && !seen[p.Cid] {
+			seen[p.Cid] = true
+			unused = append(unused, p.Cid)
+		}
+	}
+	return unused
+}
@@ -0,0 +1,74 @@
+package pins
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pins.json")
+	err := Update(path, func(r *Registry) error {
+		r.Add(Pin{Cid: "enc", Role: Enclosure, Metadata: "m1", Size: 100})
+		r.Add(Pin{Cid: "art", Role: Artwork, Metadata: "m1"})
+		r.Add(Pin{Cid: "m1", Role: Metadata, Metadata: "m1"})
+		// A new revision of the same file.
+		r.Add(Pin{Cid: "enc", Role: Enclosure, Metadata: "m2"})
+		r.Add(Pin{Cid: "m2", Role: Metadata, Metadata: "m2"})
+		r.Published("m1", "@alice/0x01", 1)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Pins) != 5 || len(r.Cids()) != 4 {
+		t.Fatalf("expected 5 pins of 4 CIDs, got %d pins of %v", len(r.Pins), r.Cids())
+	}
+	r.Add(Pin{Cid: "enc", Role: Enclosure, Metadata: "m1"})
+	if len(r.Pins) != 5 || r.Pins[0].Size != 100 || len(r.Pins[0].Casts) != 1 || r.Pins[0].Casts[0] != (Cast{"@alice/0x01", 1}) {
+		t.Errorf("Add did not update the existing pin: %+v", r.Pins[0])
+	}
+
+	unused := r.Unused(map[string]bool{"m1": true})
+	if len(unused) != 2 || unused[0] != "art" || unused[1] != "m1" {
+		t.Errorf("Unused(m1) = %v, expected [art m1]", unused)
+	}
+	r.RemoveMetadata("m1")
+	if len(r.Pins) != 2 {
+		t.Errorf("expected 2 pins after RemoveMetadata, got %d", len(r.Pins))
+	}
+	r.Remove("enc")
+	if len(r.Pins) != 1 || r.Pins[0].Cid != "m2" {
+		t.Errorf("expected only m2 after Remove, got %+v", r.Pins)
+	}
+}
+
+func TestPublishedTwice(t *testing.T) {
+	r := &Registry{}
+	r.Add(Pin{Cid: "enc", Role: Enclosure, Metadata: "m1"})
+	r.Add(Pin{Cid: "m1", Role: Metadata, Metadata: "m1"})
+	r.Published("m1", "@alice/0x01", 1)
+	r.Published("m1", "@alice/0x02", 1) // cast --cid
+	r.Published("m1", "@alice/0x02", 1)
+
+	casts := r.Casts("m1")
+	if len(casts) != 2 || casts[0].Ref != "@alice/0x01" || casts[1].Ref != "@alice/0x02" {
+		t.Fatalf("expected both casts, got %v", casts)
+	}
+	removed := map[string]bool{"@alice/0x02": true}
+	check := func(c Cast) (bool, error) { return removed[c.Ref], nil }
+	if gone, err := r.Removed("m1", check); err != nil || gone {
+		t.Errorf("m1 is still published by @alice/0x01, got removed=%v (%v)", gone, err)
+	}
+	removed["@alice/0x01"] = true
+	if gone, err := r.Removed("m1", check); err != nil || !gone {
+		t.Errorf("both casts of m1 were removed, got removed=%v (%v)", gone, err)
+	}
+	if len(r.Casts("m2")) != 0 {
+		t.Errorf("expected no casts for m2, got %v", r.Casts("m2"))
+	}
+}
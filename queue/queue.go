@@ -0,0 +1,217 @@
+/*
+Package queue stores casts scheduled with "lemon3 upload --at". The files
+are uploaded and pinned right away, and the queue keeps what is needed to
+cast them later.
+*/
+package queue
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vrypan/lemon3/errs"
+	"github.com/vrypan/lemon3/lockfile"
+)
+
+const (
+	Pending   = "pending"
+	Sending   = "sending" // claimed by a "queue run"
+	Published = "published"
+	Failed    = "failed"
+)
+
+// MaxAttempts is the number of times a cast is tried before it is marked failed.
+const MaxAttempts = 5
+
+// claimTimeout is how long an item can stay Sending before another run may take it over.
+const claimTimeout = 10 * time.Minute
+
+// Item is a scheduled cast.
+type Item struct {
+	Id        string    `json:"id"`
+	Profile   string    `json:"profile"`
+	At        time.Time `json:"at"`
+	Text      string    `json:"text"`
+	Channel   string    `json:"channel,omitempty"`    // as given by the user
+	ParentUrl string    `json:"parent_url,omitempty"` // the channel URL
+	Previews  []string  `json:"previews,omitempty"`   // preview URL templates
+	Metadata  string    `json:"metadata"`             // lemon3 metadata CID
+	Filename  string    `json:"filename"`
+	Title     string    `json:"title,omitempty"`
+	Created   time.Time `json:"created"`
+
+	Status   string    `json:"status"`
+	Claimed  time.Time `json:"claimed,omitzero"`
+	Attempts int       `json:"attempts,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Cast     string    `json:"cast,omitempty"` // @user/0xhash
+	CastAt   time.Time `json:"cast_at,omitzero"`
+}
+
+// Queue is the list of scheduled casts, sorted by time.
+type Queue struct {
+	Items []*Item `json:"items"`
+}
+
+// Load reads the queue at path. A missing file is an empty queue.
+func Load(path string) (*Queue, error) {
+	q := &Queue{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, q); err != nil {
+		return nil, fmt.Errorf("invalid queue %s: %w", path, err)
+	}
+	return q, nil
+}
+
+func (q *Queue) save(path string) error {
+	sort.SliceStable(q.Items, func(i, j int) bool { return q.Items[i].At.Before(q.Items[j].At) })
+	data, err := json.MarshalIndent(q, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+/*
+Update loads the queue at path, calls fn, and saves the queue if fn
+returns nil. A lock file keeps other lemon3 processes from changing
+the queue at the same time.
+*/
+func Update(path string, fn func(q *Queue) error) error {
+	unlock, err := lockfile.Lock(path + ".lock")
+	if err != nil {
+		return fmt.Errorf("the queue is %w", err)
+	}
+	defer unlock()
+	q, err := Load(path)
+	if err != nil {
+		return err
+	}
+	if err := fn(q); err != nil {
+		return err
+	}
+	return q.save(path)
+}
+
+// Add adds a pending item to the queue, and sets its id.
+func (q *Queue) Add(item *Item) {
+	b := make([]byte, 4)
+	for {
+		rand.Read(b)
+		item.Id = hex.EncodeToString(b)
+		if _, err := q.Find(item.Id); err != nil {
+			break
+		}
+	}
+	item.Status = Pending
+	if item.Created.IsZero() {
+		item.Created = time.Now()
+	}
+	q.Items = append(q.Items, item)
+}
+
+// Find returns the item with id, or with an id that starts with id.
+func (q *Queue) Find(id string) (*Item, error) {
+	var found *Item
+	for _, item := range q.Items {
+		if item.Id == id {
+			return item, nil
+		}
+		if id != "" && strings.HasPrefix(item.Id, id) {
+			if found != nil {
+				return nil, errs.New(errs.UserInput, "%s matches more than one queued cast", id)
+			}
+			found = item
+		}
+	}
+	if found == nil {
+		return nil, errs.New(errs.NotFound, "no queued cast with id %s", id)
+	}
+	return found, nil
+}
+
+// Remove deletes the item with id from the queue.
+func (q *Queue) Remove(id string) {
+	for i, item := range q.Items {
+		if item.Id == id {
+			q.Items = append(q.Items[:i], q.Items[i+1:]...)
+			return
+		}
+	}
+}
+
+/*
+Claim marks the items of profile that are due at now as Sending, and
+returns them. Items claimed by a run that did not finish are claimed
+again after a while.
+*/
+func (q *Queue) Claim(profile string, now time.Time) []*Item {
+	due := []*Item{}
+	for _, item := range q.Items {
+		if item.Profile != profile || item.At.After(now) {
+			continue
+		}
+		if item.Status == Pending || (item.Status == Sending && now.Sub(item.Claimed) > claimTimeout) {
+			item.Status = Sending
+			item.Claimed = now
+			due = append(due, item)
+		}
+	}
+	return due
+}
+
+// Release gives back a claimed item that was not cast, so the next run takes it at once.
+func (item *Item) Release() {
+	if item.Status == Sending {
+		item.Status = Pending
+		item.Claimed = time.Time{}
+	}
+}
+
+// Done records the result of casting item.
+func (item *Item) Done(cast string, err error, now time.Time) {
+	item.Claimed = time.Time{}
+	if err == nil {
+		item.Status = Published
+		item.Cast = cast
+		item.CastAt = now
+		item.Error = ""
+		return
+	}
+	item.Attempts++
+	item.Error = err.Error()
+	item.Status = Pending
+	if item.Attempts >= MaxAttempts {
+		item.Status = Failed
+	}
+}
+
+// Profiles returns the profiles that have items due at now.
+func (q *Queue) Profiles(now time.Time) []string {
+	profiles := []string{}
+	seen := map[string]bool{}
+	for _, item := range q.Items {
+		if item.Status != Pending && item.Status != Sending || item.At.After(now) || seen[item.Profile] {
+			continue
+		}
+		seen[item.Profile] = true
+		profiles = append(profiles, item.Profile)
+	}
+	return profiles
+}
@@ -0,0 +1,89 @@
+package queue
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	now := time.Now()
+	var id string
+	err := Update(path, func(q *Queue) error {
+		later := &Item{Profile: "default", At: now.Add(time.Hour), Metadata: "later"}
+		q.Add(later)
+		q.Add(&Item{Profile: "default", At: now.Add(-time.Minute), Metadata: "due"})
+		id = later.Id
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Items) != 2 || q.Items[0].Metadata != "due" {
+		t.Fatalf("expected 2 items sorted by time, got %+v", q.Items)
+	}
+	if item, err := q.Find(id[:4]); err != nil || item.Metadata != "later" {
+		t.Errorf("Find(%s) = %+v, %v", id[:4], item, err)
+	}
+	if _, err := q.Find("zzzz"); err == nil {
+		t.Error("expected an error for an unknown id")
+	}
+
+	// A failed update does not change the queue.
+	Update(path, func(q *Queue) error {
+		q.Remove(id)
+		return errors.New("failed")
+	})
+	if q, _ := Load(path); len(q.Items) != 2 {
+		t.Errorf("queue changed by a failed update")
+	}
+}
+
+func TestClaim(t *testing.T) {
+	now := time.Now()
+	q := &Queue{}
+	q.Add(&Item{Profile: "default", At: now.Add(-time.Minute)})
+	q.Add(&Item{Profile: "default", At: now.Add(time.Hour)})
+	q.Add(&Item{Profile: "work", At: now.Add(-time.Minute)})
+
+	if profiles := q.Profiles(now); len(profiles) != 2 {
+		t.Errorf("expected 2 profiles with due items, got %v", profiles)
+	}
+	due := q.Claim("default", now)
+	if len(due) != 1 || due[0].Status != Sending {
+		t.Fatalf("expected one claimed item, got %+v", due)
+	}
+	if again := q.Claim("default", now.Add(time.Minute)); len(again) != 0 {
+		t.Errorf("claimed item was claimed again")
+	}
+	if again := q.Claim("default", now.Add(claimTimeout+time.Minute)); len(again) != 1 {
+		t.Errorf("abandoned claim was not taken over")
+	}
+	due[0].Release()
+	if again := q.Claim("default", now.Add(time.Minute)); len(again) != 1 {
+		t.Errorf("released item was not claimed again")
+	}
+
+	item := due[0]
+	for range MaxAttempts - 1 {
+		item.Done("", errors.New("hub unreachable"), now)
+		if item.Status != Pending {
+			t.Fatalf("status = %s after %d attempts", item.Status, item.Attempts)
+		}
+	}
+	item.Done("", errors.New("hub unreachable"), now)
+	if item.Status != Failed {
+		t.Errorf("status = %s after %d attempts, expected failed", item.Status, item.Attempts)
+	}
+	item.Done("@me/0x01", nil, now)
+	if item.Status != Published || item.Cast != "@me/0x01" || item.Error != "" {
+		t.Errorf("unexpected item %+v", item)
+	}
+}